@@ -1,6 +1,7 @@
 package apitest
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"testing"
@@ -106,6 +107,125 @@ func TestGenerateRaml(t *testing.T) {
 	assert.Equal(t, expected, actual)
 }
 
+func TestGenerateMarkdown(t *testing.T) {
+	info := DocInfo{
+		Title:       "Example API",
+		Description: "Our very little example API with 2 endpoints",
+		Version:     "0.1",
+		BaseUrl:     "http://testapi.my",
+	}
+
+	generator := NewMarkdownGenerator(info)
+	tests := getTests()
+
+	doc, err := generator.Generate(tests)
+	assert.NoError(t, err, "could not generate docs")
+
+	fixture, err := ioutil.ReadFile("fixtures/markdown/markdown.md")
+	assert.NoError(t, err, "could not read fixture file")
+
+	assert.Equal(t, string(fixture), string(doc))
+}
+
+func TestGenerateHtml(t *testing.T) {
+	info := DocInfo{
+		Title:       "Example API",
+		Description: "Our very little example API with 2 endpoints",
+		Version:     "0.1",
+		BaseUrl:     "http://testapi.my",
+	}
+
+	generator := NewHtmlGenerator(info)
+	tests := getTests()
+
+	doc, err := generator.Generate(tests)
+	assert.NoError(t, err, "could not generate docs")
+
+	html := string(doc)
+	assert.Contains(t, html, "<title>Example API</title>")
+	assert.Contains(t, html, `<a href="#op-0-1"><span class="method get">GET</span> /user/{username}</a>`)
+	assert.Contains(t, html, "<summary>404: 404 error in case user not found</summary>")
+	assert.Contains(t, html, "curl -X DELETE &#39;http://testapi.my/user/octocat&#39;")
+	assert.NotContains(t, html, "<link", "no external assets expected")
+	assert.NotContains(t, html, "<script src", "no external assets expected")
+
+	// output must be stable so it can be reviewed as a diff
+	again, err := generator.Generate(tests)
+	assert.NoError(t, err, "could not generate docs")
+	assert.Equal(t, html, string(again))
+}
+
+func TestGeneratePostman(t *testing.T) {
+	info := DocInfo{
+		Title:       "Example API",
+		Description: "Our very little example API with 2 endpoints",
+		Version:     "0.1",
+		BaseUrl:     "http://testapi.my",
+	}
+
+	generator := NewPostmanGenerator(info)
+	tests := getTests()
+
+	doc, err := generator.Generate(tests)
+	assert.NoError(t, err, "could not generate collection")
+
+	assertJsonFixture(t, "fixtures/postman/collection.json", doc)
+}
+
+func TestPostmanQueryEscaped(t *testing.T) {
+	item, err := (&postmanGenerator{}).generateItem(&HelloTest{}, ApiTestCase{
+		QueryParams: ParamMap{
+			"q":    Param{Value: "a&b=c d"},
+			"tags": Param{Value: []string{"x", "y"}, CollectionFormat: CollectionPipes},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "{{baseUrl}}/hello?q=a%26b%3Dc+d&tags=x%7Cy", item.Request.Url.Raw)
+}
+
+func TestGroupTestsByTag(t *testing.T) {
+	hello, tagged, slow := &HelloTest{}, &TaggedHelloTest{}, &SlowSmokeHelloTest{}
+	tags, groups := groupTestsByTag([]IApiTest{hello, tagged, slow})
+
+	// a test is grouped under all its tags, the same way Swagger lists it
+	assert.Equal(t, []string{"critical", "default", "slow", "smoke"}, tags)
+	assert.Equal(t, []IApiTest{slow}, groups["critical"])
+	assert.Equal(t, []IApiTest{hello}, groups["default"])
+	assert.Equal(t, []IApiTest{tagged, slow}, groups["smoke"])
+}
+
+func TestGenerateInsomnia(t *testing.T) {
+	info := DocInfo{
+		Title:       "Example API",
+		Description: "Our very little example API with 2 endpoints",
+		Version:     "0.1",
+		BaseUrl:     "http://testapi.my",
+	}
+
+	generator := NewInsomniaGenerator(info)
+	tests := getTests()
+
+	doc, err := generator.Generate(tests)
+	assert.NoError(t, err, "could not generate collection")
+
+	assertJsonFixture(t, "fixtures/insomnia/export.json", doc)
+}
+
+func assertJsonFixture(t *testing.T, fixturePath string, doc []byte) {
+	var actual interface{}
+	err := json.Unmarshal(doc, &actual)
+	assert.NoError(t, err, "could not unmarshal generated doc")
+
+	fixture, err := ioutil.ReadFile(fixturePath)
+	assert.NoError(t, err, "could not read fixture file")
+
+	var expected interface{}
+	err = json.Unmarshal(fixture, &expected)
+	assert.NoError(t, err, "could not unmarshal fixture")
+
+	assert.Equal(t, expected, actual)
+}
+
 func getTests() []IApiTest {
 	return []IApiTest{
 		&HelloTest{},
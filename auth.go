@@ -0,0 +1,375 @@
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IAuthProvider authenticates requests made by the runner. Provided to the
+// runner with RunnerConfig.Auth
+type IAuthProvider interface {
+	Authenticate(req *http.Request) error
+}
+
+// IAuthProviderFunc implements IAuthProvider in a functional way
+type IAuthProviderFunc func(req *http.Request) error
+
+func (f IAuthProviderFunc) Authenticate(req *http.Request) error { return f(req) }
+
+// IAuthOverride defines interface for tests that need to be run with
+// auth that differs from the runner's one, e.g. as a different principal.
+// Returning NoAuth() or nil means the test is run unauthenticated
+type IAuthOverride interface {
+	AuthProvider() IAuthProvider
+}
+
+// NoAuth provides requests as is, with no authentication
+func NoAuth() IAuthProvider {
+	return IAuthProviderFunc(func(req *http.Request) error { return nil })
+}
+
+// describedAuth is an auth provider that knows its security scheme
+type describedAuth struct {
+	IAuthProviderFunc
+	scheme SecurityScheme
+}
+
+// SecurityScheme implements ISecurityDescriber
+func (a *describedAuth) SecurityScheme() SecurityScheme { return a.scheme }
+
+// BasicAuth authenticates requests with HTTP Basic authentication
+func BasicAuth(username, password string) IAuthProvider {
+	return &describedAuth{
+		IAuthProviderFunc: func(req *http.Request) error {
+			req.SetBasicAuth(username, password)
+			return nil
+		},
+		scheme: SecurityScheme{Type: SecurityBasic},
+	}
+}
+
+// BearerToken authenticates requests with static bearer token
+func BearerToken(token string) IAuthProvider {
+	return &describedAuth{
+		IAuthProviderFunc: func(req *http.Request) error {
+			req.Header.Set("Authorization", "Bearer "+token)
+			return nil
+		},
+		scheme: SecurityScheme{
+			Type:        SecurityApiKey,
+			Description: "Bearer token passed as 'Authorization: Bearer <token>'",
+			Name:        "Authorization",
+			In:          "header",
+		},
+	}
+}
+
+// ApiKeyHeader authenticates requests with API key passed in a header
+func ApiKeyHeader(header, key string) IAuthProvider {
+	return &describedAuth{
+		IAuthProviderFunc: func(req *http.Request) error {
+			req.Header.Set(header, key)
+			return nil
+		},
+		scheme: SecurityScheme{Type: SecurityApiKey, Name: header, In: "header"},
+	}
+}
+
+// ApiKeyQuery authenticates requests with API key passed in a query parameter
+func ApiKeyQuery(param, key string) IAuthProvider {
+	return &describedAuth{
+		IAuthProviderFunc: func(req *http.Request) error {
+			// appended as is, so the rest of the query is not re-encoded
+			pair := url.QueryEscape(param) + "=" + url.QueryEscape(key)
+			if req.URL.RawQuery != "" {
+				pair = "&" + pair
+			}
+			req.URL.RawQuery += pair
+			return nil
+		},
+		scheme: SecurityScheme{Type: SecurityApiKey, Name: param, In: "query"},
+	}
+}
+
+// authProviderFor chooses auth provider for given test
+func (r *httpRunner) authProviderFor(test IApiTest) IAuthProvider {
+	if override, ok := test.(IAuthOverride); ok {
+		return override.AuthProvider()
+	}
+	return r.Auth
+}
+
+// OAuth2Config contains settings of OAuth2 token endpoint
+type OAuth2Config struct {
+	TokenUrl     string
+	ClientId     string
+	ClientSecret string
+	Scopes       []string
+
+	// HttpClient is used to request tokens. http.Client is used by default
+	HttpClient IHttpClient
+}
+
+type oauth2Provider struct {
+	config OAuth2Config
+	grant  url.Values
+
+	mu           sync.Mutex
+	accessToken  string
+	tokenType    string
+	refreshToken string
+	expiresAt    time.Time
+}
+
+// expirySkew makes tokens to be refreshed a bit earlier than they expire,
+// so they don't expire while a request is in flight
+const expirySkew = 10 * time.Second
+
+// OAuth2ClientCredentials authenticates requests with a token obtained with
+// OAuth2 client credentials grant. The token is cached and refreshed when expired
+func OAuth2ClientCredentials(config OAuth2Config) IAuthProvider {
+	return newOAuth2Provider(config, url.Values{
+		"grant_type": {"client_credentials"},
+	})
+}
+
+// OAuth2Password authenticates requests with a token obtained with OAuth2
+// resource owner password credentials grant. The token is cached and refreshed
+// when expired
+func OAuth2Password(config OAuth2Config, username, password string) IAuthProvider {
+	return newOAuth2Provider(config, url.Values{
+		"grant_type": {"password"},
+		"username":   {username},
+		"password":   {password},
+	})
+}
+
+func newOAuth2Provider(config OAuth2Config, grant url.Values) *oauth2Provider {
+	if config.HttpClient == nil {
+		config.HttpClient = &http.Client{}
+	}
+	if len(config.Scopes) > 0 {
+		grant.Set("scope", strings.Join(config.Scopes, " "))
+	}
+
+	return &oauth2Provider{
+		config: config,
+		grant:  grant,
+	}
+}
+
+// SecurityScheme implements ISecurityDescriber
+func (p *oauth2Provider) SecurityScheme() SecurityScheme {
+	scheme := SecurityScheme{
+		Type:     SecurityOAuth2,
+		Flow:     "application",
+		TokenUrl: p.config.TokenUrl,
+		Scopes:   map[string]string{},
+	}
+	if p.grant.Get("grant_type") == "password" {
+		scheme.Flow = "password"
+	}
+	for _, scope := range p.config.Scopes {
+		scheme.Scopes[scope] = ""
+	}
+
+	return scheme
+}
+
+// Authenticate implements IAuthProvider
+func (p *oauth2Provider) Authenticate(req *http.Request) error {
+	tokenType, token, err := p.token(req.Context())
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", tokenType+" "+token)
+	return nil
+}
+
+func (p *oauth2Provider) token(ctx context.Context) (string, string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.accessToken != "" && (p.expiresAt.IsZero() || time.Now().Before(p.expiresAt)) {
+		return p.tokenType, p.accessToken, nil
+	}
+
+	var err error
+	if p.refreshToken != "" {
+		err = p.requestToken(ctx, url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {p.refreshToken},
+		})
+	}
+	if p.refreshToken == "" || err != nil {
+		// no way to refresh the token or refresh failed, requesting a new one
+		err = p.requestToken(ctx, p.grant)
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	return p.tokenType, p.accessToken, nil
+}
+
+// requestToken obtains a token, the request is bound to the context of
+// the request being authenticated
+func (p *oauth2Provider) requestToken(ctx context.Context, form url.Values) error {
+	req, err := http.NewRequest("POST", p.config.TokenUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientId != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientId), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.config.HttpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not obtain OAuth2 token: %s", err.Error())
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("could not read OAuth2 token response: %s", err.Error())
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("could not obtain OAuth2 token: server responded with %d: %s", resp.StatusCode, string(body))
+	}
+
+	token := struct {
+		AccessToken  string `json:"access_token"`
+		TokenType    string `json:"token_type"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+	}{}
+	if err = json.Unmarshal(body, &token); err != nil {
+		return fmt.Errorf("could not decode OAuth2 token response: %s", err.Error())
+	}
+	if token.AccessToken == "" {
+		return fmt.Errorf("OAuth2 token response contains no access token")
+	}
+
+	p.accessToken = token.AccessToken
+	p.tokenType = token.TokenType
+	if p.tokenType == "" || strings.EqualFold(p.tokenType, "bearer") {
+		p.tokenType = "Bearer"
+	}
+	if token.RefreshToken != "" {
+		p.refreshToken = token.RefreshToken
+	}
+	p.expiresAt = time.Time{}
+	if token.ExpiresIn > 0 {
+		p.expiresAt = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - expirySkew)
+	}
+
+	return nil
+}
+
+// HmacConfig contains settings of HMAC request signing
+type HmacConfig struct {
+	KeyId  string
+	Secret []byte
+
+	// Hash is a hash function used to sign requests, sha256 by default
+	Hash func() hash.Hash
+	// Header is a header signature is put into, Authorization by default
+	Header string
+	// Scheme prefixes the signature in the header, HMAC-SHA256 by default
+	Scheme string
+	// SignedHeaders are headers which values are included into signature
+	// in addition to the date
+	SignedHeaders []string
+}
+
+// HmacSigner authenticates requests by signing them with HMAC.
+//
+// The signature is calculated over the string that consists of following
+// lines: request method, request URI (path and query), value of X-Date
+// header (set to current time if absent), values of SignedHeaders and
+// hex encoded SHA-256 hash of the body. The result is put into the header as:
+//
+//	HMAC-SHA256 keyId="<KeyId>",headers="x-date <signed headers>",signature="<base64 signature>"
+func HmacSigner(config HmacConfig) IAuthProvider {
+	if config.Hash == nil {
+		config.Hash = sha256.New
+	}
+	if config.Header == "" {
+		config.Header = "Authorization"
+	}
+	if config.Scheme == "" {
+		config.Scheme = "HMAC-SHA256"
+	}
+
+	sign := func(req *http.Request) error {
+		body, err := peekRequestBody(req)
+		if err != nil {
+			return err
+		}
+
+		if req.Header.Get("X-Date") == "" {
+			req.Header.Set("X-Date", time.Now().UTC().Format(http.TimeFormat))
+		}
+
+		signed := []string{"x-date"}
+		lines := []string{req.Method, req.URL.RequestURI(), req.Header.Get("X-Date")}
+		for _, name := range config.SignedHeaders {
+			signed = append(signed, strings.ToLower(name))
+			lines = append(lines, req.Header.Get(name))
+		}
+		bodyHash := sha256.Sum256(body)
+		lines = append(lines, hex.EncodeToString(bodyHash[:]))
+
+		mac := hmac.New(config.Hash, config.Secret)
+		mac.Write([]byte(strings.Join(lines, "\n")))
+		signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+		req.Header.Set(config.Header, fmt.Sprintf(`%s keyId="%s",headers="%s",signature="%s"`,
+			config.Scheme, config.KeyId, strings.Join(signed, " "), signature))
+
+		return nil
+	}
+
+	return &describedAuth{
+		IAuthProviderFunc: sign,
+		scheme: SecurityScheme{
+			Type:        SecurityApiKey,
+			Description: fmt.Sprintf("Request signature: '%s keyId=\"...\",headers=\"...\",signature=\"...\"'", config.Scheme),
+			Name:        config.Header,
+			In:          "header",
+		},
+	}
+}
+
+// peekRequestBody reads body of the request leaving the request intact
+func peekRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
@@ -0,0 +1,189 @@
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaticAuthProviders(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://testapi.my/hello?a=1", nil)
+
+	assert.NoError(t, BasicAuth("user", "secret").Authenticate(req))
+	username, password, ok := req.BasicAuth()
+	assert.True(t, ok)
+	assert.Equal(t, "user", username)
+	assert.Equal(t, "secret", password)
+
+	assert.NoError(t, BearerToken("token").Authenticate(req))
+	assert.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+
+	assert.NoError(t, ApiKeyHeader("X-Api-Key", "key").Authenticate(req))
+	assert.Equal(t, "key", req.Header.Get("X-Api-Key"))
+
+	assert.NoError(t, ApiKeyQuery("api_key", "key").Authenticate(req))
+	assert.Equal(t, "http://testapi.my/hello?a=1&api_key=key", req.URL.String())
+
+	// the rest of the query is kept as is
+	req, _ = http.NewRequest("GET", "http://testapi.my/hello?z=1&a=x%20y", nil)
+	assert.NoError(t, ApiKeyQuery("api_key", "k&y").Authenticate(req))
+	assert.Equal(t, "http://testapi.my/hello?z=1&a=x%20y&api_key=k%26y", req.URL.String())
+}
+
+func TestOAuth2TokenCachingAndRefresh(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	grants := []string{}
+	httpmock.RegisterResponder("POST", "http://auth.my/token",
+		func(req *http.Request) (*http.Response, error) {
+			req.ParseForm()
+			grants = append(grants, req.PostForm.Get("grant_type"))
+
+			clientId, clientSecret, _ := req.BasicAuth()
+			if clientId != "client" || clientSecret != "secret" {
+				return httpmock.NewStringResponse(401, "bad client"), nil
+			}
+
+			// the token expires earlier than the provider's skew, so it's
+			// refreshed on every use
+			return httpmock.NewJsonResponse(200, map[string]interface{}{
+				"access_token":  "token" + req.PostForm.Get("grant_type"),
+				"token_type":    "bearer",
+				"expires_in":    1,
+				"refresh_token": "refresh",
+			})
+		},
+	)
+
+	provider := OAuth2ClientCredentials(OAuth2Config{
+		TokenUrl:     "http://auth.my/token",
+		ClientId:     "client",
+		ClientSecret: "secret",
+		Scopes:       []string{"read", "write"},
+	})
+
+	req, _ := http.NewRequest("GET", "http://testapi.my/hello", nil)
+	assert.NoError(t, provider.Authenticate(req))
+	assert.Equal(t, "Bearer tokenclient_credentials", req.Header.Get("Authorization"))
+
+	assert.NoError(t, provider.Authenticate(req))
+	assert.Equal(t, "Bearer tokenrefresh_token", req.Header.Get("Authorization"))
+	assert.Equal(t, []string{"client_credentials", "refresh_token"}, grants)
+
+	// a token with no expiration is cached
+	cached := &oauth2Provider{accessToken: "cached", tokenType: "Bearer"}
+	assert.NoError(t, cached.Authenticate(req))
+	assert.Equal(t, "Bearer cached", req.Header.Get("Authorization"))
+}
+
+func TestHmacSigner(t *testing.T) {
+	req, _ := http.NewRequest("POST", "http://testapi.my/user?a=1", strings.NewReader(`{"name":"x"}`))
+	req.Header.Set("X-Date", "Mon, 02 Jan 2006 15:04:05 GMT")
+	req.Header.Set("Content-Type", "application/json")
+
+	signer := HmacSigner(HmacConfig{KeyId: "key1", Secret: []byte("secret"), SignedHeaders: []string{"Content-Type"}})
+	assert.NoError(t, signer.Authenticate(req))
+
+	bodyHash := sha256.Sum256([]byte(`{"name":"x"}`))
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("POST\n/user?a=1\nMon, 02 Jan 2006 15:04:05 GMT\napplication/json\n" + hex.EncodeToString(bodyHash[:])))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	assert.Equal(t, `HMAC-SHA256 keyId="key1",headers="x-date content-type",signature="`+signature+`"`,
+		req.Header.Get("Authorization"))
+
+	body, err := peekRequestBody(req)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"x"}`, string(body), "body must stay intact after signing")
+}
+
+type AnonymousHelloTest struct {
+	HelloTest
+}
+
+func (t *AnonymousHelloTest) AuthProvider() IAuthProvider { return NoAuth() }
+
+func (t *AnonymousHelloTest) TestCases() []ApiTestCase {
+	return []ApiTestCase{
+		{
+			Description:      "Greeting is not available for anonymous",
+			ExpectedHttpCode: 401,
+		},
+	}
+}
+
+func TestRunnerAuth(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://testapi.my/hello",
+		func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("Authorization") != "Bearer token" {
+				return httpmock.NewBytesResponse(401, nil), nil
+			}
+			return httpmock.NewStringResponse(200, "Hello World!"), nil
+		},
+	)
+
+	runner := NewRunner("http://testapi.my", RunnerConfig{Auth: BearerToken("token")})
+	runner.Run(t, &HelloTest{}, &AnonymousHelloTest{})
+}
+
+func TestRunnerRedactsCredentials(t *testing.T) {
+	auth := IAuthProviderFunc(func(req *http.Request) error {
+		for _, provider := range []IAuthProvider{
+			BearerToken("bearer-secret"),
+			ApiKeyHeader("X-Api-Key", "header-secret"),
+			ApiKeyQuery("api_key", "query-secret"),
+		} {
+			if err := provider.Authenticate(req); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	clients := []IHttpClient{
+		IHttpClientFunc(func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(500, "")
+			resp.Header.Set("Set-Cookie", "session=cookie-secret")
+			return resp, nil
+		}),
+		IHttpClientFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, &url.Error{Op: "Get", URL: req.URL.String(), Err: errors.New("connection refused")}
+		}),
+	}
+	for _, client := range clients {
+		logs := &bytes.Buffer{}
+		runner := NewRunner("http://testapi.my", RunnerConfig{
+			HttpClient: client,
+			Auth:       auth,
+			Sink:       LogSink(log.New(logs, "", 0)),
+		})
+		report, err := runner.RunSuite(context.Background(), []IApiTest{&HelloTest{}})
+		assert.NoError(t, err)
+
+		result := report.Tests[0].Cases[0]
+		assert.False(t, result.Passed)
+		assert.Equal(t, "http://testapi.my/hello", result.Url)
+
+		output := logs.String() + strings.Join(result.Failures, "\n")
+		assert.Contains(t, output, "api_key=REDACTED")
+		for _, secret := range []string{"bearer-secret", "header-secret", "query-secret", "cookie-secret"} {
+			assert.NotContains(t, output, secret)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package apitest
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// IMaxDuration defines interface for tests that provide default latency
+// budget for their cases. ApiTestCase.MaxDuration takes precedence
+type IMaxDuration interface {
+	MaxDuration() time.Duration
+}
+
+// executeCaseWithBudget runs the test case with its hooks, repeating it if
+// requested, and checks measured round trip times against latency budgets
+// of the case once all the runs are made
+func (r *httpRunner) executeCaseWithBudget(ctx context.Context, t *caseT, test IApiTest, testCase ApiTestCase, result *CaseResult) {
+	repeat := testCase.Repeat
+	if repeat < 1 {
+		repeat = 1
+	}
+
+	maxDuration := testCase.MaxDuration
+	if budgeted, ok := test.(IMaxDuration); ok && maxDuration == 0 {
+		maxDuration = budgeted.MaxDuration()
+	}
+
+	var durations []time.Duration
+	for i := 0; i < repeat; i++ {
+		r.executeCaseWithHooks(ctx, t, test, testCase, result)
+		if len(t.failures) > 0 {
+			// no point to measure latency of a failing case
+			return
+		}
+		durations = append(durations, result.Duration)
+	}
+
+	if repeat == 1 {
+		if maxDuration > 0 && result.Duration > maxDuration {
+			t.Errorf("case '%s' took %s, that exceeds budget of %s", testCase.Description, result.Duration, maxDuration)
+		}
+		return
+	}
+
+	result.Durations = durations
+	result.P50 = percentile(durations, 50)
+	result.P95 = percentile(durations, 95)
+	t.Logf("case '%s' repeated %d times: p50 %s, p95 %s", testCase.Description, repeat, result.P50, result.P95)
+
+	if maxDuration > 0 {
+		slow := 0
+		for _, duration := range durations {
+			if duration > maxDuration {
+				slow++
+			}
+		}
+		if slow > 0 {
+			t.Errorf("%d of %d runs of case '%s' exceed budget of %s, the slowest took %s",
+				slow, repeat, testCase.Description, maxDuration, percentile(durations, 100))
+		}
+	}
+	if testCase.MaxP50 > 0 && result.P50 > testCase.MaxP50 {
+		t.Errorf("case '%s' p50 latency %s exceeds budget of %s", testCase.Description, result.P50, testCase.MaxP50)
+	}
+	if testCase.MaxP95 > 0 && result.P95 > testCase.MaxP95 {
+		t.Errorf("case '%s' p95 latency %s exceeds budget of %s", testCase.Description, result.P95, testCase.MaxP95)
+	}
+}
+
+// percentile calculates given percentile of durations using nearest-rank method
+func percentile(durations []time.Duration, p int) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,106 @@
+package apitest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type BudgetedSlowTest struct {
+	SlowTest
+	maxDuration time.Duration
+	repeat      int
+	maxP95      time.Duration
+	setUp       CaseHookFunc
+}
+
+func (t *BudgetedSlowTest) MaxDuration() time.Duration { return t.maxDuration }
+func (t *BudgetedSlowTest) TestCases() []ApiTestCase {
+	cases := t.SlowTest.TestCases()
+	cases[0].Repeat = t.repeat
+	cases[0].MaxP95 = t.maxP95
+	cases[0].SetUp = t.setUp
+	return cases
+}
+
+func TestPercentile(t *testing.T) {
+	durations := []time.Duration{}
+	for i := 20; i >= 1; i-- {
+		durations = append(durations, time.Duration(i)*time.Millisecond)
+	}
+
+	assert.Equal(t, 10*time.Millisecond, percentile(durations, 50))
+	assert.Equal(t, 19*time.Millisecond, percentile(durations, 95))
+	assert.Equal(t, 20*time.Millisecond, percentile(durations, 100))
+	assert.Equal(t, time.Duration(0), percentile(nil, 95))
+}
+
+func TestMaxDuration(t *testing.T) {
+	collector := &resultCollector{}
+	runner := NewRunner("http://testapi.my", RunnerConfig{
+		HttpClient: slowClient(20 * time.Millisecond),
+		Reporters:  []IReporter{collector},
+	})
+	runner.Run(new(testing.T), &BudgetedSlowTest{maxDuration: 5 * time.Millisecond})
+
+	result := collector.flush()[0].Cases[0]
+	assert.False(t, result.Passed)
+	if assert.Len(t, result.Failures, 1) {
+		assert.Contains(t, result.Failures[0], "exceeds budget of 5ms")
+	}
+}
+
+func TestRepeatedCaseBudget(t *testing.T) {
+	collector := &resultCollector{}
+	runner := NewRunner("http://testapi.my", RunnerConfig{
+		HttpClient: slowClient(time.Millisecond),
+		Reporters:  []IReporter{collector},
+	})
+	runner.Run(t, &BudgetedSlowTest{repeat: 10, maxP95: time.Second})
+
+	result := collector.flush()[0].Cases[0]
+	assert.True(t, result.Passed)
+	assert.Len(t, result.Durations, 10)
+	assert.True(t, result.P50 >= time.Millisecond)
+	assert.True(t, result.P95 >= result.P50)
+
+	runner = NewRunner("http://testapi.my", RunnerConfig{
+		HttpClient: slowClient(time.Millisecond),
+		Reporters:  []IReporter{collector},
+	})
+	runner.Run(new(testing.T), &BudgetedSlowTest{repeat: 3, maxP95: time.Microsecond})
+
+	result = collector.flush()[0].Cases[0]
+	assert.False(t, result.Passed)
+	if assert.Len(t, result.Failures, 1) {
+		assert.Contains(t, result.Failures[0], "p95 latency")
+	}
+}
+
+func TestRepeatedCaseMaxDuration(t *testing.T) {
+	setUps := 0
+	collector := &resultCollector{}
+	runner := NewRunner("http://testapi.my", RunnerConfig{
+		HttpClient: slowClient(20 * time.Millisecond),
+		Reporters:  []IReporter{collector},
+	})
+	runner.Run(new(testing.T), &BudgetedSlowTest{
+		repeat:      3,
+		maxDuration: 5 * time.Millisecond,
+		setUp: func(ctx *CaseContext, testCase *ApiTestCase) error {
+			setUps++
+			return nil
+		},
+	})
+
+	// slow runs don't stop sampling
+	assert.Equal(t, 3, setUps, "hooks are called for each run")
+	result := collector.flush()[0].Cases[0]
+	assert.False(t, result.Passed)
+	assert.Len(t, result.Durations, 3)
+	assert.True(t, result.P95 >= 20*time.Millisecond)
+	if assert.Len(t, result.Failures, 1) {
+		assert.Contains(t, result.Failures[0], "3 of 3 runs of case 'Slow response' exceed budget of 5ms")
+	}
+}
@@ -0,0 +1,166 @@
+package apitest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSchedule tells when monitor runs are due. Each field is a bit set
+// of allowed values
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// domRestricted and dowRestricted tell whether day fields are other
+	// than '*', since a day matches if any of restricted fields matches
+	domRestricted, dowRestricted bool
+
+	// every is set for '@every <duration>' schedules
+	every time.Duration
+}
+
+var cronAliases = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// parseCronSchedule parses standard 5-field cron expression
+// (minute, hour, day of month, month, day of week) or one of aliases:
+// @yearly, @monthly, @weekly, @daily, @hourly and '@every <duration>'
+func parseCronSchedule(spec string) (*cronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "@every ") {
+		every, err := time.ParseDuration(strings.TrimSpace(spec[len("@every "):]))
+		if err != nil {
+			return nil, fmt.Errorf("could not parse schedule '%s': %s", spec, err.Error())
+		}
+		if every <= 0 {
+			return nil, fmt.Errorf("could not parse schedule '%s': interval must be positive", spec)
+		}
+		return &cronSchedule{every: every}, nil
+	}
+	if alias, ok := cronAliases[spec]; ok {
+		spec = alias
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("could not parse schedule '%s': 5 fields expected, got %d", spec, len(fields))
+	}
+
+	bounds := []struct{ min, max uint }{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	sets := make([]uint64, 5)
+	for i, field := range fields {
+		set, err := parseCronField(field, bounds[i].min, bounds[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse schedule '%s': %s", spec, err.Error())
+		}
+		sets[i] = set
+	}
+
+	schedule := &cronSchedule{
+		minute:        sets[0],
+		hour:          sets[1],
+		dom:           sets[2],
+		month:         sets[3],
+		dow:           sets[4],
+		domRestricted: fields[2] != "*",
+		dowRestricted: fields[4] != "*",
+	}
+	// both 0 and 7 mean Sunday
+	if schedule.dow&(1<<7) != 0 {
+		schedule.dow |= 1
+	}
+
+	return schedule, nil
+}
+
+// parseCronField parses comma separated list of values, ranges and steps
+func parseCronField(field string, min, max uint) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		step := uint(1)
+		if i := strings.Index(part, "/"); i >= 0 {
+			parsed, err := strconv.ParseUint(part[i+1:], 10, 8)
+			if err != nil || parsed == 0 {
+				return 0, fmt.Errorf("invalid step in '%s'", part)
+			}
+			step = uint(parsed)
+			part = part[:i]
+		}
+
+		from, to := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			parsed, err := strconv.ParseUint(bounds[0], 10, 8)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value '%s'", part)
+			}
+			from, to = uint(parsed), uint(parsed)
+			if len(bounds) == 2 {
+				if parsed, err = strconv.ParseUint(bounds[1], 10, 8); err != nil {
+					return 0, fmt.Errorf("invalid range '%s'", part)
+				}
+				to = uint(parsed)
+			} else if step > 1 {
+				// 'n/step' means from n to the max
+				to = max
+			}
+		}
+		if from < min || to > max || from > to {
+			return 0, fmt.Errorf("value '%s' is out of range %d-%d", part, min, max)
+		}
+
+		for v := from; v <= to; v += step {
+			set |= 1 << v
+		}
+	}
+
+	return set, nil
+}
+
+// Next returns the first time after given one the schedule is due
+func (s *cronSchedule) Next(after time.Time) time.Time {
+	if s.every > 0 {
+		return after.Add(s.every)
+	}
+
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	// any valid schedule is due at least once in 5 years, leap years included
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domMatches := s.dom&(1<<uint(t.Day())) != 0
+	dowMatches := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domRestricted && s.dowRestricted {
+		return domMatches || dowMatches
+	}
+	return domMatches && dowMatches
+}
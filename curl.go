@@ -0,0 +1,215 @@
+package apitest
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ToCurl renders a shell command that reproduces a request of given
+// test case. Headers of the test case are included, default headers of
+// a runner are not.
+func ToCurl(test IApiTest, testCase ApiTestCase, baseUrl string) (string, error) {
+	r := &httpRunner{BaseUrl: baseUrl}
+	req, body, err := r.newRequest(testCase, test.Method(), test.Path())
+	if err != nil {
+		return "", err
+	}
+
+	return curlCommand(req.Method, req.URL.String(), joinHeaders(req.Header), body), nil
+}
+
+// requestToCurl renders a shell command that reproduces given request
+// for logs. Sensitive headers are redacted
+func requestToCurl(req *http.Request, body []byte) string {
+	return curlCommand(req.Method, req.URL.String(), joinHeaders(redactHeaders(req.Header)), body)
+}
+
+// joinHeaders joins values of each header
+func joinHeaders(header http.Header) map[string]string {
+	headers := map[string]string{}
+	for name, values := range header {
+		headers[name] = strings.Join(values, ", ")
+	}
+
+	return headers
+}
+
+// redacted replaces values of credentials in logged requests
+const redacted = "REDACTED"
+
+// sensitiveHeaders are always redacted, whoever has set them
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// credentials are headers and query params set by an auth provider
+type credentials struct {
+	headers []string
+	query   []string
+}
+
+// authenticate authenticates the request and tells which headers and
+// query params the provider has set, so they can be redacted in logs
+func authenticate(auth IAuthProvider, req *http.Request) (credentials, error) {
+	header := cloneHeader(req.Header)
+	query := req.URL.Query()
+
+	creds := credentials{}
+	if err := auth.Authenticate(req); err != nil {
+		return creds, err
+	}
+
+	for name, values := range req.Header {
+		if strings.Join(header[name], "\n") != strings.Join(values, "\n") {
+			creds.headers = append(creds.headers, name)
+		}
+	}
+	for name, values := range req.URL.Query() {
+		if strings.Join(query[name], "\n") != strings.Join(values, "\n") {
+			creds.query = append(creds.query, name)
+		}
+	}
+
+	return creds, nil
+}
+
+// redactRequest returns a copy of the request, that can be logged:
+// credentials and sensitive headers are replaced
+func redactRequest(req *http.Request, creds credentials) *http.Request {
+	logged := *req
+	logged.Header = redactHeaders(req.Header, creds.headers...)
+
+	u := *req.URL
+	if len(creds.query) > 0 {
+		query := u.Query()
+		for _, name := range creds.query {
+			if _, ok := query[name]; ok {
+				query.Set(name, redacted)
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+	logged.URL = &u
+
+	return &logged
+}
+
+// redactError replaces URL of a failed request with the logged one
+func redactError(err error, logReq *http.Request) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		safe := *urlErr
+		safe.URL = logReq.URL.String()
+		return &safe
+	}
+
+	return err
+}
+
+// redactHeaders returns a copy of headers with values of sensitive and
+// given headers replaced
+func redactHeaders(header http.Header, names ...string) http.Header {
+	result := cloneHeader(header)
+	for _, name := range append(names, sensitiveHeaders...) {
+		if _, ok := result[http.CanonicalHeaderKey(name)]; ok {
+			result.Set(name, redacted)
+		}
+	}
+
+	return result
+}
+
+func cloneHeader(header http.Header) http.Header {
+	result := make(http.Header, len(header))
+	for name, values := range header {
+		result[name] = append([]string(nil), values...)
+	}
+
+	return result
+}
+
+// snapshotRequestBody reads body of the request, so it can be reproduced
+// later, and puts it back
+func snapshotRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.ContentLength = int64(len(body))
+
+	return body, nil
+}
+
+// formatHeaders renders headers one per line, in alphabetical order.
+// Sensitive headers are redacted
+func formatHeaders(header http.Header) string {
+	header = redactHeaders(header)
+	buf := bytes.Buffer{}
+	for _, name := range sortedHeaderNames(header) {
+		for _, value := range header[name] {
+			buf.WriteString(name)
+			buf.WriteString(": ")
+			buf.WriteString(value)
+			buf.WriteString("\n")
+		}
+	}
+
+	return buf.String()
+}
+
+// curlCommand renders a shell command that reproduces HTTP request with
+// given method, URL, headers and body
+func curlCommand(method, url string, headers map[string]string, body []byte) string {
+	buf := bytes.Buffer{}
+	buf.WriteString("curl -X ")
+	buf.WriteString(method)
+	buf.WriteString(" ")
+	buf.WriteString(shellQuote(url))
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		buf.WriteString(" \\\n  -H ")
+		buf.WriteString(shellQuote(name + ": " + headers[name]))
+	}
+
+	if len(body) > 0 {
+		buf.WriteString(" \\\n  -d ")
+		buf.WriteString(shellQuote(string(body)))
+	}
+
+	return buf.String()
+}
+
+// shellQuote wraps given string into single quotes, so it can be safely
+// pasted into POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// sortedHeaderNames returns names of headers in alphabetical order
+func sortedHeaderNames(header http.Header) []string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
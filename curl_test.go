@@ -0,0 +1,32 @@
+package apitest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToCurl(t *testing.T) {
+	testCase := ApiTestCase{
+		Headers: ParamMap{
+			"Content-Type": Param{Value: "application/json"},
+			"X-Request-Id": Param{Value: 42},
+		},
+		PathParams: ParamMap{
+			"username": Param{Value: "octocat"},
+		},
+		QueryParams: ParamMap{
+			"fields": Param{Value: "name"},
+		},
+		RequestBody: map[string]string{"name": "it's me"},
+	}
+
+	curl, err := ToCurl(&UpdateUserTest{}, testCase, "http://testapi.my")
+	assert.NoError(t, err)
+
+	expected := `curl -X PATCH 'http://testapi.my/user/octocat?fields=name' \
+  -H 'Content-Type: application/json' \
+  -H 'X-Request-Id: 42' \
+  -d '{"name":"it'\''s me"}'`
+	assert.Equal(t, expected, curl)
+}
@@ -0,0 +1,56 @@
+package apitest
+
+import "fmt"
+
+// IDeprecatable is an interface that can tell generators and the runner
+// that the endpoint tested by the test is deprecated
+type IDeprecatable interface {
+	Deprecated() bool
+}
+
+func isDeprecated(test IApiTest) bool {
+	deprecatable, ok := test.(IDeprecatable)
+	return ok && deprecatable.Deprecated()
+}
+
+// deprecationNote prepends deprecation notice to the description, for
+// formats that have no dedicated deprecation flag
+func deprecationNote(description string) string {
+	if description == "" {
+		return "Deprecated."
+	}
+	return "Deprecated. " + description
+}
+
+// paramDescription returns description of the param for documentation
+func paramDescription(param Param) string {
+	if param.Deprecated {
+		return deprecationNote(param.Description)
+	}
+	return param.Description
+}
+
+// deprecatedInputs lists deprecated inputs the test case depends on:
+// the endpoint itself and its params, in stable order
+func deprecatedInputs(test IApiTest, testCase ApiTestCase) []string {
+	var inputs []string
+	if isDeprecated(test) {
+		inputs = append(inputs, fmt.Sprintf("endpoint %s %s", test.Method(), test.Path()))
+	}
+	for _, group := range paramGroups(testCase) {
+		for _, name := range sortedParamNames(group.params) {
+			if group.params[name].Deprecated {
+				inputs = append(inputs, fmt.Sprintf("%s param '%s'", group.kind, name))
+			}
+		}
+	}
+	return inputs
+}
+
+// warnDeprecated logs a warning for each deprecated input of the test case
+func warnDeprecated(sink IAssertionSink, test IApiTest, testCase ApiTestCase) {
+	for _, input := range deprecatedInputs(test, testCase) {
+		sink.Logf("warning: case '%s' of test '%s' depends on deprecated %s",
+			testCase.Description, extractTestName(test), input)
+	}
+}
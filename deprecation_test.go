@@ -0,0 +1,77 @@
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"testing"
+
+	"github.com/go-openapi/spec"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type LegacyHelloTest struct {
+	HelloTest
+}
+
+func (t *LegacyHelloTest) Deprecated() bool { return true }
+func (t *LegacyHelloTest) TestCases() []ApiTestCase {
+	return []ApiTestCase{
+		{
+			Description: "Greeting in given language",
+			QueryParams: ParamMap{
+				"lang":   Param{Value: "en", Description: "Language of the greeting"},
+				"locale": Param{Value: "en_US", Description: "Use lang instead", Deprecated: true},
+			},
+			ExpectedHttpCode: 200,
+			ExpectedData:     "Hello World!",
+		},
+	}
+}
+
+func TestDeprecatedDocumented(t *testing.T) {
+	out, err := NewSwaggerGeneratorJSON(spec.Swagger{}).Generate([]IApiTest{&LegacyHelloTest{}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	doc := spec.Swagger{}
+	assert.NoError(t, json.Unmarshal(out, &doc))
+	op := doc.Paths.Paths["/hello"].Get
+	assert.True(t, op.Deprecated)
+	for _, param := range op.Parameters {
+		deprecated, _ := param.Extensions.GetBool("x-deprecated")
+		assert.Equal(t, param.Name == "locale", deprecated, param.Name)
+	}
+
+	out, err = NewMarkdownGenerator(DocInfo{}).Generate([]IApiTest{&LegacyHelloTest{}})
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), "### GET /hello\n\n**Deprecated.**\n\n")
+	assert.Contains(t, string(out), "| locale | false | Deprecated. Use lang instead | `en_US` |")
+	assert.Contains(t, string(out), "| lang | false | Language of the greeting | `en` |")
+}
+
+func TestWarnDeprecated(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", "http://testapi.my/hello",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewStringResponse(200, "Hello World!"), nil
+		},
+	)
+
+	logs := &bytes.Buffer{}
+	runner := NewRunner("http://testapi.my", RunnerConfig{
+		Sink:           LogSink(log.New(logs, "", 0)),
+		WarnDeprecated: true,
+	})
+	report, err := runner.RunSuite(context.Background(), []IApiTest{&LegacyHelloTest{}})
+	assert.NoError(t, err)
+	assert.True(t, report.Passed(), "deprecation doesn't fail the case")
+	assert.Contains(t, logs.String(), "warning: case 'Greeting in given language' of test '*apitest.LegacyHelloTest' depends on deprecated endpoint GET /hello\n")
+	assert.Contains(t, logs.String(), "depends on deprecated query param 'locale'\n")
+	assert.NotContains(t, logs.String(), "'lang'")
+}
@@ -1,5 +1,12 @@
 package apitest
 
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
 // ITaggable is an interface that can tell doc generator
 // that some test provides a tag. Usable for swagger documentation
 // where tags help to group API endpoints
@@ -12,3 +19,127 @@ type ITaggable interface {
 type IDocGenerator interface {
 	Generate(tests []IApiTest) ([]byte, error)
 }
+
+// DocInfo contains general information about documented API. It's used
+// as a seed by generators that don't have their own specification format
+type DocInfo struct {
+	Title       string
+	Description string
+	Version     string
+	BaseUrl     string
+}
+
+// defaultTag is used to group tests that don't provide any tag
+const defaultTag = "default"
+
+// groupTestsByTag splits tests into groups by their tags. A test with several
+// tags goes into each of their groups, the same way Swagger UI lists it.
+// Tags are returned sorted, order of tests within a group is preserved.
+func groupTestsByTag(tests []IApiTest) ([]string, map[string][]IApiTest) {
+	groups := map[string][]IApiTest{}
+	for _, test := range tests {
+		tags := testTags(test)
+		if len(tags) == 0 {
+			tags = []string{defaultTag}
+		}
+		grouped := map[string]bool{}
+		for _, tag := range tags {
+			if !grouped[tag] {
+				grouped[tag] = true
+				groups[tag] = append(groups[tag], test)
+			}
+		}
+	}
+
+	tags := make([]string, 0, len(groups))
+	for tag := range groups {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	return tags, groups
+}
+
+// collectParams gathers all header, path and query parameters used by
+// test cases of given test. If a parameter is used by several test cases,
+// the first occurrence wins.
+func collectParams(test IApiTest) (headers, path, query ParamMap) {
+	headers, path, query = ParamMap{}, ParamMap{}, ParamMap{}
+	tmpl := templateOf(test)
+	for _, testCase := range test.TestCases() {
+		for key, param := range testCase.Headers {
+			if _, ok := headers[key]; !ok {
+				headers[key] = param
+			}
+		}
+		pathParams, queryParams := tmpl.splitParams(docPathParams(tmpl, testCase))
+		for key, param := range pathParams {
+			if _, ok := path[key]; !ok {
+				param.Required = true // path parameters are always required
+				path[key] = param
+			}
+		}
+		for key, param := range queryParams {
+			if _, ok := query[key]; !ok {
+				query[key] = param
+			}
+		}
+		for key, param := range testCase.QueryParams {
+			if _, ok := query[key]; !ok {
+				query[key] = param
+			}
+		}
+	}
+
+	return headers, path, query
+}
+
+// sortedParamNames returns names of parameters in alphabetical order
+func sortedParamNames(params ParamMap) []string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// paramValueString converts parameter value to a string the same way
+// as test runner does when puts it into a request. Items of slice values
+// are joined according to the collection format, 'multi' params are joined
+// with commas where they can't be repeated
+func paramValueString(param Param) string {
+	if items, ok := paramItems(param.Value); ok {
+		return strings.Join(itemStrings(items), collectionSeparator(param.CollectionFormat))
+	}
+	return valueString(param.Value)
+}
+
+func valueString(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	if stringValue, ok := value.(string); ok {
+		return stringValue
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// exampleString renders example data as indented JSON. Strings are
+// rendered as is since API may respond with plain text.
+func exampleString(data interface{}) string {
+	if data == nil {
+		return ""
+	}
+	if s, ok := data.(string); ok {
+		return s
+	}
+
+	// TODO: right now it supports json, but should support marshaller depending on MIME type
+	content, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%v", data)
+	}
+	return string(content)
+}
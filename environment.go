@@ -0,0 +1,253 @@
+package apitest
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// EnvironmentVar is an environment variable that selects environment
+// if -apitest.env flag is not provided
+const EnvironmentVar = "APITEST_ENV"
+
+var environmentFlag = flag.String("apitest.env", "", "name of environment to run API tests against")
+
+// IEnvironmentOptOut defines interface for tests that must not be run in
+// some environments, e.g. destructive tests in production
+type IEnvironmentOptOut interface {
+	ExcludedEnvironments() []string
+}
+
+// Environments is a set of environment profiles, usually loaded from a file:
+//
+//	default: dev
+//	environments:
+//	  dev:
+//	    base_url: http://localhost:8080
+//	    headers:
+//	      X-Debug: "1"
+//	  prod:
+//	    base_url: https://api.example.com
+//	    auth:
+//	      type: bearer
+//	      token: ${PROD_TOKEN}
+//	    vars:
+//	      user: octocat
+type Environments struct {
+	// Default is used if environment is not selected explicitly
+	Default      string                 `json:"default"`
+	Environments map[string]Environment `json:"environments"`
+}
+
+// Environment describes an instance of the API tests are run against
+type Environment struct {
+	Name    string                 `json:"-"`
+	BaseUrl string                 `json:"base_url"`
+	Headers map[string]string      `json:"headers"`
+	Auth    *AuthConfig            `json:"auth"`
+	Vars    map[string]interface{} `json:"vars"`
+}
+
+// AuthConfig describes one of built-in auth providers. Type is one of
+// basic, bearer, api_key_header, api_key_query, oauth2_client_credentials
+// and oauth2_password
+type AuthConfig struct {
+	Type string `json:"type"`
+
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Token    string `json:"token"`
+
+	// Header or Param is a name API Key is passed in
+	Header string `json:"header"`
+	Param  string `json:"param"`
+	Key    string `json:"key"`
+
+	TokenUrl     string   `json:"token_url"`
+	ClientId     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	Scopes       []string `json:"scopes"`
+}
+
+// envVarPattern matches references to environment variables like ${TOKEN}
+var envVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// LoadEnvironments reads environment profiles from YAML or JSON file.
+// References to environment variables like ${TOKEN} are expanded, so
+// secrets don't need to be kept in the file. Other uses of '$' are kept
+// as is. It's an error to reference a variable that is not set
+func LoadEnvironments(filename string) (*Environments, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("could not read environments: %s", err.Error())
+	}
+
+	content, err = expandEnvVars(content)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseEnvironments(content)
+}
+
+// expandEnvVars replaces ${VAR} references with values of environment variables
+func expandEnvVars(content []byte) ([]byte, error) {
+	var unset []string
+	seen := map[string]bool{}
+	expanded := envVarPattern.ReplaceAllFunc(content, func(ref []byte) []byte {
+		name := string(envVarPattern.FindSubmatch(ref)[1])
+		value, ok := os.LookupEnv(name)
+		if !ok && !seen[name] {
+			seen[name] = true
+			unset = append(unset, name)
+		}
+		return []byte(value)
+	})
+	if len(unset) > 0 {
+		return nil, fmt.Errorf("could not expand environments, variables are not set: %s", strings.Join(unset, ", "))
+	}
+
+	return expanded, nil
+}
+
+// ParseEnvironments parses environment profiles from YAML or JSON
+func ParseEnvironments(content []byte) (*Environments, error) {
+	envs := &Environments{}
+	if err := yaml.Unmarshal(content, envs); err != nil {
+		return nil, fmt.Errorf("could not parse environments: %s", err.Error())
+	}
+	if len(envs.Environments) == 0 {
+		return nil, fmt.Errorf("no environments defined")
+	}
+
+	for name, env := range envs.Environments {
+		if env.BaseUrl == "" {
+			return nil, fmt.Errorf("environment '%s' has no base_url", name)
+		}
+		env.Name = name
+		envs.Environments[name] = env
+	}
+	if envs.Default != "" {
+		if _, ok := envs.Environments[envs.Default]; !ok {
+			return nil, fmt.Errorf("default environment '%s' is not defined", envs.Default)
+		}
+	}
+
+	return envs, nil
+}
+
+// SelectedEnvironment returns name of environment selected with
+// -apitest.env flag or APITEST_ENV variable, empty string if none
+func SelectedEnvironment() string {
+	if *environmentFlag != "" {
+		return *environmentFlag
+	}
+	return os.Getenv(EnvironmentVar)
+}
+
+// Select returns environment with given name. If the name is empty, the
+// selected environment is used (see SelectedEnvironment), then the default one
+func (e *Environments) Select(name string) (*Environment, error) {
+	if name == "" {
+		name = SelectedEnvironment()
+	}
+	if name == "" {
+		name = e.Default
+	}
+	if name == "" {
+		return nil, fmt.Errorf("no environment selected, use -apitest.env flag or %s variable", EnvironmentVar)
+	}
+
+	env, ok := e.Environments[name]
+	if !ok {
+		names := make([]string, 0, len(e.Environments))
+		for envName := range e.Environments {
+			names = append(names, envName)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown environment '%s', available: %s", name, strings.Join(names, ", "))
+	}
+
+	return &env, nil
+}
+
+// NewRunner creates a runner for the environment. Headers, auth and
+// variables of the environment are applied on top of given config
+func (e *Environment) NewRunner(config RunnerConfig) (*httpRunner, error) {
+	headers := map[string]string{}
+	for name, value := range config.DefaultHeaders {
+		headers[name] = value
+	}
+	for name, value := range e.Headers {
+		headers[name] = value
+	}
+	config.DefaultHeaders = headers
+
+	vars := map[string]interface{}{}
+	for name, value := range config.Vars {
+		vars[name] = value
+	}
+	for name, value := range e.Vars {
+		vars[name] = value
+	}
+	config.Vars = vars
+
+	if e.Auth != nil {
+		auth, err := e.Auth.Provider()
+		if err != nil {
+			return nil, fmt.Errorf("environment '%s': %s", e.Name, err.Error())
+		}
+		config.Auth = auth
+	}
+	config.Environment = e.Name
+
+	return NewRunner(e.BaseUrl, config), nil
+}
+
+// Provider creates auth provider described by the config
+func (c *AuthConfig) Provider() (IAuthProvider, error) {
+	oauth2 := OAuth2Config{
+		TokenUrl:     c.TokenUrl,
+		ClientId:     c.ClientId,
+		ClientSecret: c.ClientSecret,
+		Scopes:       c.Scopes,
+	}
+
+	switch c.Type {
+	case "basic":
+		return BasicAuth(c.Username, c.Password), nil
+	case "bearer":
+		return BearerToken(c.Token), nil
+	case "api_key_header":
+		return ApiKeyHeader(c.Header, c.Key), nil
+	case "api_key_query":
+		return ApiKeyQuery(c.Param, c.Key), nil
+	case "oauth2_client_credentials":
+		return OAuth2ClientCredentials(oauth2), nil
+	case "oauth2_password":
+		return OAuth2Password(oauth2, c.Username, c.Password), nil
+	}
+
+	return nil, fmt.Errorf("unknown auth type '%s'", c.Type)
+}
+
+// excludedFrom tells whether the test opted out of given environment
+func excludedFrom(test IApiTest, environment string) bool {
+	optOut, ok := test.(IEnvironmentOptOut)
+	if !ok || environment == "" {
+		return false
+	}
+
+	for _, excluded := range optOut.ExcludedEnvironments() {
+		if excluded == environment {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package apitest
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const environmentsYAML = `
+default: dev
+environments:
+  dev:
+    base_url: http://testapi.my
+    headers:
+      X-Env: dev
+    vars:
+      user: octocat
+  prod:
+    base_url: http://prod.testapi.my
+    headers:
+      X-Env: prod
+    auth:
+      type: bearer
+      token: ${APITEST_PROD_TOKEN}
+`
+
+type DestructiveHelloTest struct {
+	HelloTest
+}
+
+func (t *DestructiveHelloTest) ExcludedEnvironments() []string { return []string{"prod"} }
+
+func TestLoadEnvironments(t *testing.T) {
+	dir, err := ioutil.TempDir("", "apitest-env")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "environments.yaml")
+	assert.NoError(t, ioutil.WriteFile(filename, []byte(environmentsYAML), 0644))
+	os.Setenv("APITEST_PROD_TOKEN", "secret")
+	defer os.Unsetenv("APITEST_PROD_TOKEN")
+
+	envs, err := LoadEnvironments(filename)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	env, err := envs.Select("")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "dev", env.Name)
+		assert.Equal(t, "http://testapi.my", env.BaseUrl)
+		assert.Equal(t, "octocat", env.Vars["user"])
+	}
+
+	os.Setenv(EnvironmentVar, "prod")
+	env, err = envs.Select("")
+	os.Unsetenv(EnvironmentVar)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "prod", env.Name)
+		assert.Equal(t, &AuthConfig{Type: "bearer", Token: "secret"}, env.Auth)
+	}
+
+	_, err = envs.Select("qa")
+	assert.EqualError(t, err, "unknown environment 'qa', available: dev, prod")
+
+	_, err = ParseEnvironments([]byte("environments:\n  dev: {}\n"))
+	assert.EqualError(t, err, "environment 'dev' has no base_url")
+}
+
+func TestLoadEnvironmentsExpansion(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "environments.yaml")
+	os.Setenv("APITEST_PROD_TOKEN", "secret")
+	defer os.Unsetenv("APITEST_PROD_TOKEN")
+
+	// only ${VAR} references are expanded, other '$' are literal
+	content := environmentsYAML + "    vars:\n      price: $5\n      password: pa$$word$HOME\n"
+	assert.NoError(t, ioutil.WriteFile(filename, []byte(content), 0644))
+	envs, err := LoadEnvironments(filename)
+	if assert.NoError(t, err) {
+		prod := envs.Environments["prod"]
+		assert.Equal(t, "secret", prod.Auth.Token)
+		assert.Equal(t, "$5", prod.Vars["price"])
+		assert.Equal(t, "pa$$word$HOME", prod.Vars["password"])
+	}
+
+	content = environmentsYAML + "    vars:\n      user: ${APITEST_MISSING_USER}\n      pass: ${APITEST_MISSING_PASS}\n"
+	assert.NoError(t, ioutil.WriteFile(filename, []byte(content), 0644))
+	_, err = LoadEnvironments(filename)
+	assert.EqualError(t, err, "could not expand environments, variables are not set: APITEST_MISSING_USER, APITEST_MISSING_PASS")
+}
+
+func TestEnvironmentRunner(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://prod.testapi.my/hello",
+		func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("X-Env") != "prod" || req.Header.Get("Authorization") != "Bearer secret" {
+				return httpmock.NewStringResponse(401, ""), nil
+			}
+			return httpmock.NewStringResponse(200, "Hello World!"), nil
+		},
+	)
+
+	envs, err := ParseEnvironments([]byte(environmentsYAML))
+	if !assert.NoError(t, err) {
+		return
+	}
+	env, _ := envs.Select("prod")
+	env.Auth.Token = "secret"
+
+	runner, err := env.NewRunner(RunnerConfig{DefaultHeaders: map[string]string{"X-Env": "none", "Accept": "*/*"}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, map[string]string{"X-Env": "prod", "Accept": "*/*"}, runner.DefaultHeaders)
+
+	report, err := runner.RunSuite(context.Background(), []IApiTest{&HelloTest{}, &DestructiveHelloTest{}})
+	assert.NoError(t, err)
+	assert.True(t, report.Passed())
+	assert.Len(t, report.Tests, 1, "destructive test is not run in prod")
+
+	env.Auth.Type = "kerberos"
+	_, err = env.NewRunner(RunnerConfig{})
+	assert.EqualError(t, err, "environment 'prod': unknown auth type 'kerberos'")
+}
+
+type DestructiveCreatePostTest struct {
+	CreatePostTest
+}
+
+func (t *DestructiveCreatePostTest) ExcludedEnvironments() []string { return []string{"prod"} }
+
+func TestEnvironmentGatesLoadAndFuzz(t *testing.T) {
+	var requests int64
+	runner := NewRunner("http://prod.testapi.my", RunnerConfig{
+		HttpClient:  loadClient(&requests),
+		Environment: "prod",
+	})
+
+	report, err := runner.Load(context.Background(), LoadConfig{Duration: 20 * time.Millisecond},
+		&HelloTest{}, &DestructiveHelloTest{})
+	if assert.NoError(t, err) {
+		assert.Len(t, report.Tests, 1, "destructive test is not run in prod")
+		assert.Equal(t, "*apitest.HelloTest", report.Tests[0].Name)
+	}
+
+	_, err = runner.Load(context.Background(), LoadConfig{Duration: 20 * time.Millisecond}, &DestructiveHelloTest{})
+	assert.EqualError(t, err, "no test cases to run under load")
+
+	runner.Filter = TestFilter{Names: []string{"GetUserTest"}}
+	_, err = runner.Load(context.Background(), LoadConfig{Duration: 20 * time.Millisecond}, &HelloTest{})
+	assert.EqualError(t, err, "no test cases to run under load", "filtered out test is not run")
+	runner.Filter = TestFilter{}
+
+	atomic.StoreInt64(&requests, 0)
+	runner.Fuzz(t, FuzzConfig{Iterations: 10, Seed: 1}, &DestructiveCreatePostTest{})
+	assert.Equal(t, int64(0), atomic.LoadInt64(&requests), "destructive test is not fuzzed in prod")
+
+	selected, err := runner.selectTests(t.Logf, []IApiTest{&CreatePostTest{}, &DestructiveCreatePostTest{}})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []IApiTest{&CreatePostTest{}}, selected)
+	}
+}
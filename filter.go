@@ -0,0 +1,195 @@
+package apitest
+
+import (
+	"flag"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+)
+
+var (
+	tagsFlag   = flag.String("apitest.tags", "", "tag expression selecting API tests, e.g. 'smoke+!slow,critical'")
+	namesFlag  = flag.String("apitest.run", "", "comma separated globs of names of API tests to run, e.g. 'Hello*' or '*apitest.Hello*'")
+	methodFlag = flag.String("apitest.method", "", "comma separated HTTP methods of API tests to run")
+	pathFlag   = flag.String("apitest.path", "", "comma separated globs of paths of API tests to run")
+)
+
+// IMultiTaggable defines interface for tests that provide several tags.
+// Tags of ITaggable and IMultiTaggable are combined if a test implements both
+type IMultiTaggable interface {
+	Tags() []string
+}
+
+// testTags returns all the tags of the test
+func testTags(test IApiTest) []string {
+	var tags []string
+	if taggable, ok := test.(ITaggable); ok && taggable.Tag() != "" {
+		tags = append(tags, taggable.Tag())
+	}
+	if taggable, ok := test.(IMultiTaggable); ok {
+		for _, tag := range taggable.Tags() {
+			if tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// TestFilter selects tests to run. Empty fields match any test
+type TestFilter struct {
+	// Tags is a tag expression: comma separated alternatives, each of them
+	// is a '+' separated list of tags the test must have. Tags prefixed with
+	// '!' must be absent. E.g. 'smoke+!slow,critical' selects tests tagged
+	// 'critical' and tests tagged 'smoke' but not 'slow'
+	Tags string
+	// Names are globs of test names, as reported by the runner, e.g.
+	// '*apitest.HelloTest'. Type names with no package, e.g. 'HelloTest',
+	// are matched as well
+	Names []string
+	// Methods are HTTP methods, case insensitive
+	Methods []string
+	// Paths are globs of test paths, e.g. '/user/*'
+	Paths []string
+}
+
+// flagFilter builds a filter from command line flags
+func flagFilter() TestFilter {
+	return TestFilter{
+		Tags:    *tagsFlag,
+		Names:   splitFlagList(*namesFlag),
+		Methods: splitFlagList(*methodFlag),
+		Paths:   splitFlagList(*pathFlag),
+	}
+}
+
+func splitFlagList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// tagTerm is a tag that must be present or absent
+type tagTerm struct {
+	tag    string
+	negate bool
+}
+
+// parseTagExpression parses tag expression into alternatives of
+// conjunctions of terms
+func parseTagExpression(expression string) ([][]tagTerm, error) {
+	var alternatives [][]tagTerm
+	for _, alternative := range strings.Split(expression, ",") {
+		if strings.TrimSpace(alternative) == "" {
+			continue
+		}
+
+		var terms []tagTerm
+		for _, term := range strings.Split(alternative, "+") {
+			term = strings.TrimSpace(term)
+			negate := strings.HasPrefix(term, "!")
+			tag := strings.TrimSpace(strings.TrimPrefix(term, "!"))
+			if tag == "" {
+				return nil, fmt.Errorf("could not parse tag expression '%s': empty tag", expression)
+			}
+			terms = append(terms, tagTerm{tag: tag, negate: negate})
+		}
+		alternatives = append(alternatives, terms)
+	}
+	return alternatives, nil
+}
+
+// Validate checks that the filter is well formed
+func (f TestFilter) Validate() error {
+	if _, err := parseTagExpression(f.Tags); err != nil {
+		return err
+	}
+	for _, pattern := range append(append([]string{}, f.Names...), f.Paths...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid glob '%s': %s", pattern, err.Error())
+		}
+	}
+	return nil
+}
+
+// Match tells whether the test is selected by the filter. Malformed
+// expressions and globs match nothing, see Validate
+func (f TestFilter) Match(test IApiTest) bool {
+	return f.matchTags(test) &&
+		f.matchName(test) &&
+		matchGlobs(f.Paths, test.Path()) &&
+		f.matchMethod(test)
+}
+
+func (f TestFilter) matchTags(test IApiTest) bool {
+	alternatives, err := parseTagExpression(f.Tags)
+	if err != nil {
+		return false
+	}
+	if len(alternatives) == 0 {
+		return true
+	}
+
+	tags := map[string]bool{}
+	for _, tag := range testTags(test) {
+		tags[tag] = true
+	}
+
+	for _, terms := range alternatives {
+		matched := true
+		for _, term := range terms {
+			if tags[term.tag] == term.negate {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (f TestFilter) matchName(test IApiTest) bool {
+	if matchGlobs(f.Names, extractTestName(test)) {
+		return true
+	}
+	if _, ok := test.(INameable); ok {
+		return false
+	}
+
+	tpe := reflect.TypeOf(test)
+	if tpe.Kind() == reflect.Ptr {
+		tpe = tpe.Elem()
+	}
+	return tpe.Name() != "" && matchGlobs(f.Names, tpe.Name())
+}
+
+func (f TestFilter) matchMethod(test IApiTest) bool {
+	if len(f.Methods) == 0 {
+		return true
+	}
+	for _, method := range f.Methods {
+		if strings.EqualFold(method, test.Method()) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchGlobs(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, value); matched {
+			return true
+		}
+	}
+	return false
+}
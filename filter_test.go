@@ -0,0 +1,63 @@
+package apitest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type SlowSmokeHelloTest struct {
+	TaggedHelloTest
+}
+
+func (t *SlowSmokeHelloTest) Tags() []string { return []string{"slow", "critical"} }
+
+func TestTestFilter(t *testing.T) {
+	tagged, slow := &TaggedHelloTest{}, &SlowSmokeHelloTest{}
+	assert.Equal(t, []string{"smoke", "slow", "critical"}, testTags(slow))
+
+	for expression, expected := range map[string][2]bool{
+		"":                     {true, true},
+		"smoke":                {true, true},
+		"smoke+!slow":          {true, false},
+		"!smoke,critical":      {false, true},
+		"smoke+slow, critical": {false, true},
+		"none":                 {false, false},
+	} {
+		filter := TestFilter{Tags: expression}
+		assert.NoError(t, filter.Validate(), expression)
+		assert.Equal(t, expected[0], filter.Match(tagged), expression)
+		assert.Equal(t, expected[1], filter.Match(slow), expression)
+	}
+
+	assert.True(t, TestFilter{Names: []string{"*Slow*"}}.Match(slow))
+	assert.False(t, TestFilter{Names: []string{"*Slow*"}}.Match(tagged))
+	assert.True(t, TestFilter{Names: []string{"SlowSmoke*"}}.Match(slow), "type name with no package matches")
+	assert.False(t, TestFilter{Names: []string{"TaggedHelloTest"}}.Match(slow))
+	assert.True(t, TestFilter{Methods: []string{"post", "get"}}.Match(&GetUserTest{}))
+	assert.False(t, TestFilter{Methods: []string{"DELETE"}}.Match(&GetUserTest{}))
+	assert.True(t, TestFilter{Paths: []string{"/user/*"}}.Match(&GetUserTest{}))
+	assert.False(t, TestFilter{Paths: []string{"/user/*"}}.Match(&CreateUserTest{}))
+
+	assert.EqualError(t, TestFilter{Tags: "smoke+"}.Validate(), "could not parse tag expression 'smoke+': empty tag")
+	assert.Error(t, TestFilter{Paths: []string{"/user/["}}.Validate())
+}
+
+func TestRunFiltered(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	setupMock()
+
+	runner := NewRunner("http://testapi.my", RunnerConfig{Filter: TestFilter{Tags: "smoke"}})
+	report, err := runner.RunSuite(context.Background(), []IApiTest{&HelloTest{}, &TaggedHelloTest{}})
+	assert.NoError(t, err)
+	if assert.Len(t, report.Tests, 1) {
+		assert.Equal(t, "*apitest.TaggedHelloTest", report.Tests[0].Name)
+	}
+
+	runner = NewRunner("http://testapi.my", RunnerConfig{Filter: TestFilter{Tags: "!"}})
+	_, err = runner.RunSuite(context.Background(), []IApiTest{&HelloTest{}})
+	assert.EqualError(t, err, "invalid test filter: could not parse tag expression '!': empty tag")
+}
@@ -0,0 +1,383 @@
+package apitest
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// FuzzConfig contains options of fuzzing run
+type FuzzConfig struct {
+	// Iterations is a number of random inputs generated for each test case
+	// in addition to boundary ones. 100 by default
+	Iterations int
+	// Seed initializes random generator, so a failing run can be
+	// reproduced. Current time is used by default
+	Seed int64
+}
+
+// Kind of fuzzing target that is a top level field of JSON request body
+const fuzzKindBody = "body"
+
+// fuzzTarget is an input of the endpoint that can be fuzzed
+type fuzzTarget struct {
+	kind     string
+	name     string
+	jsonType string
+}
+
+// fuzzMutation replaces value of the target with generated one
+type fuzzMutation struct {
+	target fuzzTarget
+	value  interface{}
+}
+
+func (m fuzzMutation) String() string {
+	value := fmt.Sprintf("%#v", m.value)
+	if len(value) > 80 {
+		value = value[:80] + fmt.Sprintf("...(%d bytes)", len(value))
+	}
+	return fmt.Sprintf("%s '%s' = %s", m.target.kind, m.target.name, value)
+}
+
+// fuzzFailure describes an input that made the endpoint fail
+type fuzzFailure struct {
+	testCase  ApiTestCase
+	mutations []fuzzMutation
+	httpCode  int
+	err       error
+	curl      string
+}
+
+func (f fuzzFailure) String() string {
+	inputs := make([]string, len(f.mutations))
+	for i, mutation := range f.mutations {
+		inputs[i] = mutation.String()
+	}
+
+	outcome := fmt.Sprintf("responded with %d", f.httpCode)
+	if f.err != nil {
+		outcome = fmt.Sprintf("failed: %s", f.err.Error())
+	}
+
+	return fmt.Sprintf("case '%s' %s for input: %s\nrequest:\n%s",
+		f.testCase.Description, outcome, strings.Join(inputs, ", "), f.curl)
+}
+
+// fuzzSource provides randomness for generated inputs
+type fuzzSource interface {
+	Intn(n int) int
+}
+
+// fuzzBoundaryValues are values that are likely to break input handling,
+// by type of the parameter. Each type includes values of wrong types
+var fuzzBoundaryValues = map[string][]interface{}{
+	"integer": {0, -1, int64(math.MaxInt64), int64(math.MinInt64), "18446744073709551616", "1.5", "abc", ""},
+	"number":  {0, -1.5, math.MaxFloat64, "NaN", "Infinity", "1e309", "abc", ""},
+	"boolean": {"", "maybe", 2, "null"},
+	"string": {"", strings.Repeat("a", 10000), "Ωμέγα ✓ 漢字 😀", "' OR '1'='1", "../../etc/passwd",
+		"%00", "{{7*7}}", -1, true},
+}
+
+// fuzzRunePools are sets of runes random strings are made of
+var fuzzRunePools = [][]rune{
+	[]rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"),
+	[]rune(" !\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"),
+	[]rune("ÀÉÎÕÜßñøΩμέγαЖЯ漢字日本語😀👍🏽\u200b\ufeff"),
+}
+
+// Fuzz stresses endpoints with generated inputs: boundary values and random
+// values of headers, query and path params and top level fields of request
+// body, based on types of values of successful (2xx) test cases that are not
+// skipped or pending. The endpoint is expected to never respond with 5xx.
+// Failing inputs are shrunk to minimal reproduction before they are reported.
+// Tests are selected by filters and environment like in Run.
+func (r *httpRunner) Fuzz(t *testing.T, config FuzzConfig, tests ...IApiTest) {
+	if config.Iterations == 0 {
+		config.Iterations = 100
+	}
+	if config.Seed == 0 {
+		config.Seed = time.Now().UnixNano()
+	}
+	tests, err := r.selectTests(t.Logf, tests)
+	if err != nil {
+		t.Errorf("%s", err.Error())
+		return
+	}
+	t.Logf("fuzzing with seed %d", config.Seed)
+
+	src := rand.New(rand.NewSource(config.Seed))
+	for _, test := range tests {
+		testName := extractTestName(test)
+		for _, failure := range r.fuzzTest(test, config.Iterations, src) {
+			t.Errorf("fuzzing test '%s'(%s) with seed %d: %s", testName, test.Description(), config.Seed, failure)
+		}
+	}
+}
+
+// fuzzTest fuzzes all successful cases of the test and returns failures
+// found, one per distinct minimal input
+func (r *httpRunner) fuzzTest(test IApiTest, iterations int, src fuzzSource) []fuzzFailure {
+	var failures []fuzzFailure
+	for _, testCase := range test.TestCases() {
+		if testCase.ExpectedHttpCode < 200 || testCase.ExpectedHttpCode >= 300 || testCase.skipReason() != "" {
+			continue
+		}
+
+		targets := fuzzTargets(testCase)
+		if len(targets) == 0 {
+			continue
+		}
+
+		seen := map[string]bool{}
+		check := func(mutations []fuzzMutation) {
+			failure, failed := r.tryFuzzInput(test, testCase, mutations)
+			if !failed {
+				return
+			}
+
+			failure = r.shrinkFuzzInput(test, testCase, failure)
+			key := fmt.Sprintf("%v", failure.mutations)
+			if !seen[key] {
+				seen[key] = true
+				failures = append(failures, failure)
+			}
+		}
+
+		for _, target := range targets {
+			for _, value := range fuzzBoundaryValues[target.jsonType] {
+				check([]fuzzMutation{{target: target, value: value}})
+			}
+		}
+		for i := 0; i < iterations; i++ {
+			check(randomFuzzMutations(src, targets))
+		}
+	}
+
+	return failures
+}
+
+// fuzzTargets lists inputs of the test case that can be fuzzed
+func fuzzTargets(testCase ApiTestCase) []fuzzTarget {
+	var targets []fuzzTarget
+	for _, group := range []struct {
+		kind   string
+		params ParamMap
+	}{
+		{paramKindHeader, testCase.Headers},
+		{paramKindQuery, testCase.QueryParams},
+		{paramKindPath, testCase.PathParams},
+	} {
+		for _, name := range sortedParamNames(group.params) {
+			jsonType, err := generateSpecSimpleType(group.params[name].Value)
+			if err != nil {
+				jsonType = "string"
+			}
+			targets = append(targets, fuzzTarget{kind: group.kind, name: name, jsonType: jsonType})
+		}
+	}
+
+	if testCase.RequestBody != nil {
+		if body, err := objToJsonMap(testCase.RequestBody); err == nil {
+			fields := make([]string, 0, len(body))
+			for field := range body {
+				fields = append(fields, field)
+			}
+			sort.Strings(fields)
+
+			for _, field := range fields {
+				targets = append(targets, fuzzTarget{kind: fuzzKindBody, name: field, jsonType: jsonValueType(body[field])})
+			}
+		}
+	}
+
+	return targets
+}
+
+// jsonValueType tells type of decoded JSON value. Complex values are fuzzed as strings
+func jsonValueType(value interface{}) string {
+	switch v := value.(type) {
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	}
+	return "string"
+}
+
+// randomFuzzMutations generates from 1 to 3 mutations of random targets
+func randomFuzzMutations(src fuzzSource, targets []fuzzTarget) []fuzzMutation {
+	count := 1 + src.Intn(3)
+	mutations := []fuzzMutation{}
+	used := map[int]bool{}
+	for i := 0; i < count; i++ {
+		index := src.Intn(len(targets))
+		if used[index] {
+			continue
+		}
+		used[index] = true
+
+		target := targets[index]
+		mutations = append(mutations, fuzzMutation{target: target, value: randomFuzzValue(src, target)})
+	}
+
+	return mutations
+}
+
+// randomFuzzValue generates either a boundary value or a random value of random type
+func randomFuzzValue(src fuzzSource, target fuzzTarget) interface{} {
+	if src.Intn(2) == 0 {
+		values := fuzzBoundaryValues[target.jsonType]
+		return values[src.Intn(len(values))]
+	}
+
+	switch src.Intn(4) {
+	case 0:
+		return src.Intn(2001) - 1000
+	case 1:
+		value := int64(1) << uint(src.Intn(63))
+		if src.Intn(2) == 0 {
+			value = -value
+		}
+		return value
+	case 2:
+		if target.kind == fuzzKindBody {
+			return nil
+		}
+		return src.Intn(2) == 0
+	}
+
+	return randomFuzzString(src)
+}
+
+// randomFuzzString generates a string of random length made of random runes.
+// Control characters are never generated, so the string is a valid header value
+func randomFuzzString(src fuzzSource) string {
+	lengths := []int{1, 8, 64, 1024}
+	length := src.Intn(lengths[src.Intn(len(lengths))] + 1)
+
+	result := make([]rune, length)
+	for i := range result {
+		pool := fuzzRunePools[src.Intn(len(fuzzRunePools))]
+		result[i] = pool[src.Intn(len(pool))]
+	}
+
+	return string(result)
+}
+
+// applyFuzzMutations returns a copy of test case with mutated inputs
+func applyFuzzMutations(testCase ApiTestCase, mutations []fuzzMutation) ApiTestCase {
+	testCase.Headers = copyParams(testCase.Headers)
+	testCase.QueryParams = copyParams(testCase.QueryParams)
+	testCase.PathParams = copyParams(testCase.PathParams)
+
+	var body map[string]interface{}
+	for _, mutation := range mutations {
+		target := mutation.target
+		switch target.kind {
+		case paramKindHeader:
+			testCase.Headers[target.name] = Param{Value: mutation.value}
+		case paramKindQuery:
+			testCase.QueryParams[target.name] = Param{Value: mutation.value}
+		case paramKindPath:
+			testCase.PathParams[target.name] = Param{Value: mutation.value}
+		case fuzzKindBody:
+			if body == nil {
+				body, _ = objToJsonMap(testCase.RequestBody)
+			}
+			body[target.name] = mutation.value
+		}
+	}
+	if body != nil {
+		testCase.RequestBody = body
+	}
+
+	return testCase
+}
+
+func copyParams(params ParamMap) ParamMap {
+	result := ParamMap{}
+	for name, param := range params {
+		result[name] = param
+	}
+	return result
+}
+
+// tryFuzzInput sends the test case with given mutations. The input is
+// considered failing if the server responds with 5xx or the request fails.
+// Inputs that can't be turned into a request are not considered failing
+func (r *httpRunner) tryFuzzInput(test IApiTest, testCase ApiTestCase, mutations []fuzzMutation) (fuzzFailure, bool) {
+	failure := fuzzFailure{testCase: testCase, mutations: mutations}
+
+	req, requestBody, creds, err := r.prepareRequest(context.Background(), test, applyFuzzMutations(testCase, mutations))
+	if err != nil {
+		return failure, false
+	}
+	logReq := redactRequest(req, creds)
+	failure.curl = requestToCurl(logReq, requestBody)
+
+	req, _, cancel := r.withTimeout(context.Background(), req, testCase)
+	defer cancel()
+
+	resp, err := r.HttpClient.Do(req)
+	if err != nil {
+		failure.err = redactError(err, logReq)
+		return failure, true
+	}
+	if resp.Body != nil {
+		ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	failure.httpCode = resp.StatusCode
+
+	return failure, resp.StatusCode >= 500
+}
+
+// shrinkFuzzInput looks for minimal input that still fails: drops mutations
+// that don't matter, then shortens string values
+func (r *httpRunner) shrinkFuzzInput(test IApiTest, testCase ApiTestCase, failure fuzzFailure) fuzzFailure {
+	for shrunk := true; shrunk && len(failure.mutations) > 1; {
+		shrunk = false
+		for i := range failure.mutations {
+			mutations := append(append([]fuzzMutation{}, failure.mutations[:i]...), failure.mutations[i+1:]...)
+			if smaller, failed := r.tryFuzzInput(test, testCase, mutations); failed {
+				failure = smaller
+				shrunk = true
+				break
+			}
+		}
+	}
+
+	for i, mutation := range failure.mutations {
+		value, ok := mutation.value.(string)
+		if !ok {
+			continue
+		}
+
+		// binary search of the shortest failing prefix
+		runes := []rune(value)
+		lo, hi := -1, len(runes)
+		for hi-lo > 1 {
+			mid := (lo + hi) / 2
+			mutations := append([]fuzzMutation{}, failure.mutations...)
+			mutations[i].value = string(runes[:mid])
+			if smaller, failed := r.tryFuzzInput(test, testCase, mutations); failed {
+				failure = smaller
+				hi = mid
+			} else {
+				lo = mid
+			}
+		}
+	}
+
+	return failure
+}
@@ -0,0 +1,68 @@
+//go:build go1.18
+// +build go1.18
+
+package apitest
+
+import "testing"
+
+// FuzzCase integrates fuzzing of a successful test case with native Go
+// fuzzing, so inputs are generated by the fuzzing engine and failing ones
+// are saved into testdata/fuzz. Boundary values of all the inputs are
+// added to the seed corpus.
+//
+// Usage:
+//
+//	func FuzzGetUser(f *testing.F) {
+//	    runner := apitest.NewRunner(baseUrl, apitest.RunnerConfig{})
+//	    test := &GetUserTest{}
+//	    runner.FuzzCase(f, test, test.TestCases()[0])
+//	}
+func (r *httpRunner) FuzzCase(f *testing.F, test IApiTest, testCase ApiTestCase) {
+	selected, err := r.selectTests(f.Logf, []IApiTest{test})
+	if err != nil {
+		f.Fatalf("%s", err.Error())
+	}
+	if len(selected) == 0 {
+		f.Skip("test is not selected to run")
+	}
+
+	if reason := testCase.skipReason(); reason != "" {
+		f.Skipf("test case is skipped: %s", reason)
+	}
+
+	targets := fuzzTargets(testCase)
+	if len(targets) == 0 {
+		f.Skip("test case has no inputs to fuzz")
+	}
+
+	for i, target := range targets {
+		for j := range fuzzBoundaryValues[target.jsonType] {
+			// decoded by randomFuzzMutations as a single boundary mutation
+			f.Add([]byte{0, byte(i), 0, byte(j)})
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		mutations := randomFuzzMutations(&byteSource{data: data}, targets)
+		if failure, failed := r.tryFuzzInput(test, testCase, mutations); failed {
+			t.Errorf("fuzzing test '%s'(%s): %s", extractTestName(test), test.Description(),
+				r.shrinkFuzzInput(test, testCase, failure))
+		}
+	})
+}
+
+// byteSource turns input of Go fuzzing engine into a fuzzSource
+type byteSource struct {
+	data []byte
+}
+
+// Intn implements fuzzSource. Returns 0 when the input is exhausted
+func (s *byteSource) Intn(n int) int {
+	if len(s.data) == 0 {
+		return 0
+	}
+	b := s.data[0]
+	s.data = s.data[1:]
+
+	return int(b) % n
+}
@@ -0,0 +1,104 @@
+package apitest
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type CreatePostTest struct{}
+
+func (t *CreatePostTest) Method() string      { return "POST" }
+func (t *CreatePostTest) Description() string { return "Test for creating a post" }
+func (t *CreatePostTest) Path() string        { return "/posts" }
+func (t *CreatePostTest) TestCases() []ApiTestCase {
+	return []ApiTestCase{
+		{
+			Description: "Post created",
+			QueryParams: ParamMap{"limit": Param{Value: 10}},
+			RequestBody: map[string]interface{}{
+				"title": "Hello",
+				"draft": false,
+			},
+			ExpectedHttpCode: 201,
+		},
+		{
+			Description:      "Post not created",
+			ExpectedHttpCode: 400,
+		},
+	}
+}
+
+func TestFuzzTargets(t *testing.T) {
+	targets := fuzzTargets((&CreatePostTest{}).TestCases()[0])
+	assert.Equal(t, []fuzzTarget{
+		{kind: paramKindQuery, name: "limit", jsonType: "integer"},
+		{kind: fuzzKindBody, name: "draft", jsonType: "boolean"},
+		{kind: fuzzKindBody, name: "title", jsonType: "string"},
+	}, targets)
+
+	src := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		mutations := randomFuzzMutations(src, targets)
+		assert.True(t, len(mutations) >= 1 && len(mutations) <= 3)
+		for _, mutation := range mutations {
+			if s, ok := mutation.value.(string); ok {
+				assert.True(t, utf8.ValidString(s))
+			}
+		}
+	}
+}
+
+func TestFuzz(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	// the endpoint crashes on long titles and non-numeric limits
+	httpmock.RegisterResponder("POST", "http://testapi.my/posts",
+		func(req *http.Request) (*http.Response, error) {
+			if _, err := strconv.Atoi(req.URL.Query().Get("limit")); err != nil {
+				return httpmock.NewStringResponse(500, "bad limit"), nil
+			}
+
+			body := map[string]interface{}{}
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				return httpmock.NewStringResponse(400, "bad body"), nil
+			}
+			if title, ok := body["title"].(string); ok && utf8.RuneCountInString(title) > 100 {
+				return httpmock.NewStringResponse(500, "title is too long"), nil
+			}
+
+			return httpmock.NewStringResponse(201, ""), nil
+		},
+	)
+
+	runner := NewRunner("http://testapi.my", RunnerConfig{})
+	failures := runner.fuzzTest(&CreatePostTest{}, 50, rand.New(rand.NewSource(1)))
+
+	shortestTitle := 0
+	for _, failure := range failures {
+		if assert.Len(t, failure.mutations, 1, "input is shrunk to single mutation") {
+			mutation := failure.mutations[0]
+			switch mutation.target.name {
+			case "limit":
+				assert.Equal(t, 500, failure.httpCode)
+			case "title":
+				length := utf8.RuneCountInString(mutation.value.(string))
+				if shortestTitle == 0 || length < shortestTitle {
+					shortestTitle = length
+				}
+			default:
+				t.Errorf("unexpected failure: %s", failure)
+			}
+		}
+		assert.Contains(t, failure.curl, "curl -X POST")
+	}
+
+	assert.Equal(t, 101, shortestTitle, "long title is shrunk to minimal failing length")
+}
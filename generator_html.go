@@ -0,0 +1,314 @@
+package apitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+
+	"github.com/alecthomas/jsonschema"
+)
+
+type htmlGenerator struct {
+	docOptions
+	info DocInfo
+}
+
+// NewHtmlGenerator creates an instance of HTML generator. It produces
+// a single self-contained page with no external assets.
+// info is used as a source of general data about the API, info.BaseUrl
+// is used to build request examples.
+func NewHtmlGenerator(info DocInfo) IDocGenerator {
+	return &htmlGenerator{
+		info: info,
+	}
+}
+
+type htmlDoc struct {
+	Info DocInfo
+	Tags []htmlTag
+}
+
+type htmlTag struct {
+	Name      string
+	Endpoints []htmlEndpoint
+}
+
+type htmlEndpoint struct {
+	ID          string
+	Method      string
+	Path        string
+	Description string
+	Params      []htmlParamGroup
+	Examples    []htmlExample
+}
+
+type htmlParamGroup struct {
+	Title  string
+	Params []htmlParam
+}
+
+type htmlParam struct {
+	Name        string
+	Required    bool
+	Description string
+	Example     string
+}
+
+type htmlExample struct {
+	Description  string
+	HttpCode     int
+	Curl         string
+	RequestBody  string
+	RequestType  []htmlSchemaField
+	ResponseBody string
+	ResponseType []htmlSchemaField
+}
+
+type htmlSchemaField struct {
+	Name     string
+	Type     string
+	Format   string
+	Required bool
+}
+
+// Generate implements IDocGenerator
+func (g *htmlGenerator) Generate(tests []IApiTest) ([]byte, error) {
+	if err := validateTestPaths(tests); err != nil {
+		return nil, err
+	}
+
+	doc := htmlDoc{Info: g.info}
+
+	tags, groups := groupTestsByTag(tests)
+	for tagIndex, tag := range tags {
+		htag := htmlTag{Name: tag}
+		for testIndex, test := range groups[tag] {
+			endpoint, err := g.generateEndpoint(test)
+			if err != nil {
+				return nil, err
+			}
+			endpoint.ID = fmt.Sprintf("op-%d-%d", tagIndex, testIndex)
+			htag.Endpoints = append(htag.Endpoints, endpoint)
+		}
+		doc.Tags = append(doc.Tags, htag)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := htmlTemplate.Execute(buf, doc); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (g *htmlGenerator) generateEndpoint(test IApiTest) (htmlEndpoint, error) {
+	endpoint := htmlEndpoint{
+		Method:      test.Method(),
+		Path:        templateOf(test).docPath(),
+		Description: test.Description(),
+	}
+
+	headers, path, query := collectParams(test)
+	endpoint.Params = []htmlParamGroup{
+		{Title: "Header parameters", Params: htmlParams(headers)},
+		{Title: "Path parameters", Params: htmlParams(path)},
+		{Title: "Query parameters", Params: htmlParams(query)},
+	}
+
+	for _, testCase := range test.TestCases() {
+		example, err := g.generateExample(test, testCase)
+		if err != nil {
+			return endpoint, err
+		}
+		endpoint.Examples = append(endpoint.Examples, example)
+	}
+
+	return endpoint, nil
+}
+
+func (g *htmlGenerator) generateExample(test IApiTest, testCase ApiTestCase) (htmlExample, error) {
+	example := htmlExample{
+		Description: g.caseDescription(testCase),
+		HttpCode:    testCase.ExpectedHttpCode,
+	}
+
+	url, err := docCaseUrl(test, testCase, g.info.BaseUrl, func(name string) string { return "{" + name + "}" })
+	if err != nil {
+		return example, fmt.Errorf("could not prepare an url for '%s %s': %s", test.Method(), test.Path(), err.Error())
+	}
+
+	headers := map[string]string{}
+	for name, param := range testCase.Headers {
+		headers[name] = paramValueString(param)
+	}
+
+	var body []byte
+	if testCase.RequestBody != nil {
+		// TODO: right now it supports json, but should support marshaller depending on MIME type
+		if body, err = json.Marshal(testCase.RequestBody); err != nil {
+			return example, fmt.Errorf("could not encode request body for '%s %s': %s", test.Method(), test.Path(), err.Error())
+		}
+
+		example.RequestBody = exampleString(testCase.RequestBody)
+		example.RequestType = htmlSchemaFields(testCase.RequestBody)
+	}
+	example.Curl = curlCommand(test.Method(), url, headers, body)
+
+	if testCase.ExpectedData != nil {
+		example.ResponseBody = exampleString(testCase.ExpectedData)
+		example.ResponseType = htmlSchemaFields(testCase.ExpectedData)
+	}
+
+	return example, nil
+}
+
+func htmlParams(params ParamMap) []htmlParam {
+	result := []htmlParam{}
+	for _, name := range sortedParamNames(params) {
+		param := params[name]
+		result = append(result, htmlParam{
+			Name:        name,
+			Required:    param.Required,
+			Description: paramDescription(param),
+			Example:     paramValueString(paramExample(param)),
+		})
+	}
+	return result
+}
+
+// htmlSchemaFields reflects a schema of given item and lists properties
+// of the top level object. Returns nothing if item is not an object.
+func htmlSchemaFields(item interface{}) []htmlSchemaField {
+	schema := jsonschema.Reflect(item)
+	root := resolveJsonType(schema.Type, schema.Definitions)
+	if root == nil || len(root.Properties) == 0 {
+		return nil
+	}
+
+	required := map[string]bool{}
+	for _, name := range root.Required {
+		required[name] = true
+	}
+
+	names := make([]string, 0, len(root.Properties))
+	for name := range root.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := make([]htmlSchemaField, 0, len(names))
+	for _, name := range names {
+		prop := root.Properties[name]
+		fields = append(fields, htmlSchemaField{
+			Name:     name,
+			Type:     jsonTypeName(prop),
+			Format:   prop.Format,
+			Required: required[name],
+		})
+	}
+
+	return fields
+}
+
+// resolveJsonType follows a reference to definition if given type is a reference
+func resolveJsonType(t *jsonschema.Type, defs map[string]*jsonschema.Type) *jsonschema.Type {
+	if t == nil || t.Ref == "" {
+		return t
+	}
+	return defs[jsonRefName(t.Ref)]
+}
+
+// jsonTypeName provides human readable name of JSON schema type
+func jsonTypeName(t *jsonschema.Type) string {
+	if t.Ref != "" {
+		return jsonRefName(t.Ref)
+	}
+	if t.Type == "array" && t.Items != nil {
+		return "array of " + jsonTypeName(t.Items)
+	}
+	return t.Type
+}
+
+func jsonRefName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+var htmlTemplate = template.Must(template.New("doc").Funcs(template.FuncMap{
+	"lower": strings.ToLower,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Info.Title}}</title>
+<style>
+body { margin: 0; font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #222; }
+nav { position: fixed; top: 0; bottom: 0; left: 0; width: 280px; overflow-y: auto; background: #f5f5f5; border-right: 1px solid #ddd; padding: 16px; box-sizing: border-box; }
+nav input { width: 100%; padding: 6px; margin-bottom: 12px; box-sizing: border-box; }
+nav h3 { margin: 12px 0 4px; font-size: 14px; text-transform: uppercase; color: #666; }
+nav ul { list-style: none; margin: 0; padding: 0; }
+nav li a { display: block; padding: 3px 0; color: #222; text-decoration: none; font-size: 13px; }
+main { margin-left: 280px; padding: 24px 40px; }
+section.endpoint { border-bottom: 1px solid #eee; padding-bottom: 16px; margin-bottom: 24px; }
+.method { display: inline-block; min-width: 60px; padding: 2px 6px; border-radius: 3px; color: #fff; font-size: 12px; font-weight: bold; text-align: center; background: #777; }
+.method.get { background: #2f80ed; } .method.post { background: #27ae60; } .method.put { background: #f2994a; }
+.method.patch { background: #9b51e0; } .method.delete { background: #eb5757; }
+code, pre { font-family: Menlo, Consolas, monospace; font-size: 13px; }
+pre { background: #f8f8f8; border: 1px solid #eee; padding: 8px; overflow-x: auto; }
+table { border-collapse: collapse; margin: 8px 0; }
+th, td { border: 1px solid #ddd; padding: 4px 8px; text-align: left; font-size: 13px; }
+details { margin: 8px 0; }
+summary { cursor: pointer; font-weight: bold; }
+</style>
+</head>
+<body>
+<nav>
+<input type="search" id="search" placeholder="Search..." oninput="filterEndpoints(this.value)">
+{{range .Tags}}<h3>{{.Name}}</h3>
+<ul>
+{{range .Endpoints}}<li data-search="{{lower .Method}} {{lower .Path}} {{lower .Description}}"><a href="#{{.ID}}"><span class="method {{lower .Method}}">{{.Method}}</span> {{.Path}}</a></li>
+{{end}}</ul>
+{{end}}</nav>
+<main>
+{{if .Info.Title}}<h1>{{.Info.Title}}</h1>
+{{end}}{{if .Info.Version}}<p>Version: {{.Info.Version}}</p>
+{{end}}{{if .Info.Description}}<p>{{.Info.Description}}</p>
+{{end}}{{range .Tags}}<h2>{{.Name}}</h2>
+{{range .Endpoints}}<section class="endpoint" id="{{.ID}}" data-search="{{lower .Method}} {{lower .Path}} {{lower .Description}}">
+<h3><span class="method {{lower .Method}}">{{.Method}}</span> <code>{{.Path}}</code></h3>
+{{if .Description}}<p>{{.Description}}</p>
+{{end}}{{range .Params}}{{template "params" .}}{{end}}{{range .Examples}}<details>
+<summary>{{.HttpCode}}: {{.Description}}</summary>
+<h5>Request</h5>
+<pre>{{.Curl}}</pre>
+{{if .RequestBody}}<h5>Request body</h5>
+{{template "schema" .RequestType}}<pre>{{.RequestBody}}</pre>
+{{end}}<h5>Response {{.HttpCode}}</h5>
+{{if .ResponseBody}}{{template "schema" .ResponseType}}<pre>{{.ResponseBody}}</pre>
+{{else}}<p><em>empty</em></p>
+{{end}}</details>
+{{end}}</section>
+{{end}}{{end}}</main>
+<script>
+function filterEndpoints(query) {
+  query = query.toLowerCase();
+  var items = document.querySelectorAll("[data-search]");
+  for (var i = 0; i < items.length; i++) {
+    items[i].style.display = items[i].getAttribute("data-search").indexOf(query) >= 0 ? "" : "none";
+  }
+}
+</script>
+</body>
+</html>
+{{define "params"}}{{if .Params}}<h4>{{.Title}}</h4>
+<table>
+<tr><th>Name</th><th>Required</th><th>Description</th><th>Example</th></tr>
+{{range .Params}}<tr><td>{{.Name}}</td><td>{{.Required}}</td><td>{{.Description}}</td><td><code>{{.Example}}</code></td></tr>
+{{end}}</table>
+{{end}}{{end}}{{define "schema"}}{{if .}}<table>
+<tr><th>Field</th><th>Type</th><th>Format</th><th>Required</th></tr>
+{{range .}}<tr><td>{{.Name}}</td><td>{{.Type}}</td><td>{{.Format}}</td><td>{{.Required}}</td></tr>
+{{end}}</table>
+{{end}}{{end}}`))
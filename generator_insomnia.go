@@ -0,0 +1,173 @@
+package apitest
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const insomniaWorkspaceId = "wrk_apitest"
+
+type insomniaGenerator struct {
+	docOptions
+	info DocInfo
+}
+
+// NewInsomniaGenerator creates an instance of generator that exports tests
+// as Insomnia v4 export. Each test case becomes a request, requests
+// are put into folders by tests' tags. info.BaseUrl becomes a value of
+// 'baseUrl' environment variable.
+func NewInsomniaGenerator(info DocInfo) IDocGenerator {
+	return &insomniaGenerator{
+		info: info,
+	}
+}
+
+type insomniaExport struct {
+	Type      string             `json:"_type"`
+	Format    int                `json:"__export_format"`
+	Source    string             `json:"__export_source"`
+	Resources []insomniaResource `json:"resources"`
+}
+
+// insomniaResource is a union of all resource types used in export:
+// workspace, environment, request group and request
+type insomniaResource struct {
+	Id          string            `json:"_id"`
+	Type        string            `json:"_type"`
+	ParentId    *string           `json:"parentId"`
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Data        map[string]string `json:"data,omitempty"`
+	Method      string            `json:"method,omitempty"`
+	Url         string            `json:"url,omitempty"`
+	Body        *insomniaBody     `json:"body,omitempty"`
+	Parameters  []insomniaPair    `json:"parameters,omitempty"`
+	Headers     []insomniaPair    `json:"headers,omitempty"`
+}
+
+type insomniaBody struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type insomniaPair struct {
+	Name        string `json:"name"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+}
+
+// Generate implements IDocGenerator
+func (g *insomniaGenerator) Generate(tests []IApiTest) ([]byte, error) {
+	if err := validateTestPaths(tests); err != nil {
+		return nil, err
+	}
+
+	workspaceId := insomniaWorkspaceId
+	export := insomniaExport{
+		Type:   "export",
+		Format: 4,
+		Source: "apitest",
+		Resources: []insomniaResource{
+			{
+				Id:          workspaceId,
+				Type:        "workspace",
+				Name:        g.info.Title,
+				Description: g.info.Description,
+			},
+			{
+				Id:       "env_apitest",
+				Type:     "environment",
+				ParentId: &workspaceId,
+				Name:     "Base Environment",
+				Data:     map[string]string{baseUrlVariable: g.info.BaseUrl},
+			},
+		},
+	}
+
+	tags, groups := groupTestsByTag(tests)
+	for tagIndex, tag := range tags {
+		folderId := fmt.Sprintf("fld_%d", tagIndex)
+		export.Resources = append(export.Resources, insomniaResource{
+			Id:       folderId,
+			Type:     "request_group",
+			ParentId: &workspaceId,
+			Name:     tag,
+		})
+
+		requestIndex := 0
+		for _, test := range groups[tag] {
+			for _, testCase := range test.TestCases() {
+				request, err := g.generateInsomniaRequest(test, testCase)
+				if err != nil {
+					return nil, err
+				}
+
+				parentId := folderId
+				request.Id = fmt.Sprintf("req_%d_%d", tagIndex, requestIndex)
+				request.ParentId = &parentId
+				export.Resources = append(export.Resources, request)
+				requestIndex++
+			}
+		}
+	}
+
+	return json.MarshalIndent(export, "", "  ")
+}
+
+func (g *insomniaGenerator) generateInsomniaRequest(test IApiTest, testCase ApiTestCase) (insomniaResource, error) {
+	// insomnia does not support path variables, so they are expanded in place,
+	// the ones with no value refer to environment. Query parameters are
+	// provided separately
+	pathCase := testCase
+	pathCase.QueryParams = nil
+	path, err := docCaseUrl(test, pathCase, "", func(name string) string { return "{{ _." + name + " }}" })
+	if err != nil {
+		return insomniaResource{}, fmt.Errorf("could not prepare an url for '%s %s': %s", test.Method(), test.Path(), err.Error())
+	}
+
+	name := collectionItemName(test, testCase)
+	if testCase.Description != "" {
+		name = g.caseDescription(testCase)
+	}
+	request := insomniaResource{
+		Type:        "request",
+		Name:        name,
+		Description: test.Description(),
+		Method:      test.Method(),
+		Url:         "{{ _." + baseUrlVariable + " }}" + path,
+	}
+
+	for _, name := range sortedParamNames(testCase.Headers) {
+		param := testCase.Headers[name]
+		request.Headers = append(request.Headers, insomniaPair{
+			Name:        name,
+			Value:       paramValueString(param),
+			Description: param.Description,
+		})
+	}
+
+	for _, name := range sortedParamNames(testCase.QueryParams) {
+		param := testCase.QueryParams[name]
+		for _, value := range paramQueryValues(param) {
+			request.Parameters = append(request.Parameters, insomniaPair{
+				Name:        name,
+				Value:       value,
+				Description: param.Description,
+			})
+		}
+	}
+
+	if testCase.RequestBody != nil {
+		// TODO: right now it supports json, but should support marshaller depending on MIME type
+		content, err := json.MarshalIndent(testCase.RequestBody, "", "  ")
+		if err != nil {
+			return request, fmt.Errorf("could not encode request body for '%s %s': %s", test.Method(), test.Path(), err.Error())
+		}
+		request.Body = &insomniaBody{
+			MimeType: "application/json",
+			Text:     string(content),
+		}
+	}
+
+	return request, nil
+}
@@ -0,0 +1,159 @@
+package apitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type markdownGenerator struct {
+	docOptions
+	info DocInfo
+}
+
+// NewMarkdownGenerator creates an instance of Markdown generator.
+// info is used as a source of general data about the API, info.BaseUrl
+// is used to build request examples.
+func NewMarkdownGenerator(info DocInfo) IDocGenerator {
+	return &markdownGenerator{
+		info: info,
+	}
+}
+
+// Generate implements IDocGenerator
+func (g *markdownGenerator) Generate(tests []IApiTest) ([]byte, error) {
+	if err := validateTestPaths(tests); err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+
+	if g.info.Title != "" {
+		fmt.Fprintf(buf, "# %s\n\n", g.info.Title)
+	}
+	if g.info.Version != "" {
+		fmt.Fprintf(buf, "Version: %s\n\n", g.info.Version)
+	}
+	if g.info.Description != "" {
+		fmt.Fprintf(buf, "%s\n\n", g.info.Description)
+	}
+
+	tags, groups := groupTestsByTag(tests)
+	for _, tag := range tags {
+		fmt.Fprintf(buf, "## %s\n\n", tag)
+
+		for _, test := range groups[tag] {
+			if err := g.generateEndpoint(buf, test); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (g *markdownGenerator) generateEndpoint(buf *bytes.Buffer, test IApiTest) error {
+	fmt.Fprintf(buf, "### %s %s\n\n", test.Method(), templateOf(test).docPath())
+	if isDeprecated(test) {
+		buf.WriteString("**Deprecated.**\n\n")
+	}
+	if test.Description() != "" {
+		fmt.Fprintf(buf, "%s\n\n", test.Description())
+	}
+
+	headers, path, query := collectParams(test)
+	writeMarkdownParams(buf, "Header parameters", headers)
+	writeMarkdownParams(buf, "Path parameters", path)
+	writeMarkdownParams(buf, "Query parameters", query)
+
+	if len(test.TestCases()) == 0 {
+		return nil
+	}
+
+	buf.WriteString("#### Examples\n\n")
+	for _, testCase := range test.TestCases() {
+		if err := g.generateExample(buf, test, testCase); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (g *markdownGenerator) generateExample(buf *bytes.Buffer, test IApiTest, testCase ApiTestCase) error {
+	fmt.Fprintf(buf, "##### %d: %s\n\n", testCase.ExpectedHttpCode, g.caseDescription(testCase))
+
+	url, err := docCaseUrl(test, testCase, g.info.BaseUrl, func(name string) string { return "{" + name + "}" })
+	if err != nil {
+		return fmt.Errorf("could not prepare an url for '%s %s': %s", test.Method(), test.Path(), err.Error())
+	}
+
+	headers := map[string]string{}
+	for name, param := range testCase.Headers {
+		headers[name] = paramValueString(param)
+	}
+
+	var body []byte
+	if testCase.RequestBody != nil {
+		// TODO: right now it supports json, but should support marshaller depending on MIME type
+		if body, err = json.Marshal(testCase.RequestBody); err != nil {
+			return fmt.Errorf("could not encode request body for '%s %s': %s", test.Method(), test.Path(), err.Error())
+		}
+	}
+
+	buf.WriteString("Request:\n\n")
+	writeMarkdownCode(buf, "sh", curlCommand(test.Method(), url, headers, body))
+
+	if testCase.RequestBody != nil {
+		buf.WriteString("Request body:\n\n")
+		writeMarkdownCode(buf, exampleLang(testCase.RequestBody), exampleString(testCase.RequestBody))
+	}
+
+	fmt.Fprintf(buf, "Response `%d`:\n\n", testCase.ExpectedHttpCode)
+	if testCase.ExpectedData != nil {
+		writeMarkdownCode(buf, exampleLang(testCase.ExpectedData), exampleString(testCase.ExpectedData))
+	} else {
+		buf.WriteString("_empty_\n\n")
+	}
+
+	return nil
+}
+
+func writeMarkdownParams(buf *bytes.Buffer, title string, params ParamMap) {
+	if len(params) == 0 {
+		return
+	}
+
+	fmt.Fprintf(buf, "#### %s\n\n", title)
+	buf.WriteString("| Name | Required | Description | Example |\n")
+	buf.WriteString("|------|----------|-------------|---------|\n")
+	for _, name := range sortedParamNames(params) {
+		param := params[name]
+		fmt.Fprintf(buf, "| %s | %t | %s | `%s` |\n",
+			markdownCell(name),
+			param.Required,
+			markdownCell(paramDescription(param)),
+			markdownCell(paramValueString(paramExample(param))),
+		)
+	}
+	buf.WriteString("\n")
+}
+
+func writeMarkdownCode(buf *bytes.Buffer, lang, code string) {
+	fmt.Fprintf(buf, "```%s\n%s\n```\n\n", lang, code)
+}
+
+// exampleLang returns language of a code block rendered by exampleString
+func exampleLang(data interface{}) string {
+	if _, ok := data.(string); ok {
+		return ""
+	}
+	return "json"
+}
+
+// markdownCell escapes a string so it can be safely put into a table cell
+func markdownCell(s string) string {
+	s = strings.Replace(s, "|", `\|`, -1)
+	return strings.Replace(s, "\n", " ", -1)
+}
@@ -0,0 +1,230 @@
+package apitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// baseUrlVariable is a name of environment variable that replaces
+// base URL of the API in exported collections
+const baseUrlVariable = "baseUrl"
+
+var pathVariableRegexp = regexp.MustCompile(`\{([^{}]+)\}`)
+
+type postmanGenerator struct {
+	docOptions
+	info DocInfo
+}
+
+// NewPostmanGenerator creates an instance of generator that exports tests
+// as Postman Collection v2.1. Each test case becomes a request, requests
+// are put into folders by tests' tags. info.BaseUrl becomes a value of
+// 'baseUrl' collection variable.
+func NewPostmanGenerator(info DocInfo) IDocGenerator {
+	return &postmanGenerator{
+		info: info,
+	}
+}
+
+type postmanCollection struct {
+	Info     postmanInfo       `json:"info"`
+	Item     []postmanItem     `json:"item"`
+	Variable []postmanKeyValue `json:"variable"`
+}
+
+type postmanInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version,omitempty"`
+	Schema      string `json:"schema"`
+}
+
+type postmanItem struct {
+	Name     string            `json:"name"`
+	Item     []postmanItem     `json:"item,omitempty"`
+	Request  *postmanRequest   `json:"request,omitempty"`
+	Response []postmanResponse `json:"response,omitempty"`
+}
+
+type postmanRequest struct {
+	Method      string            `json:"method"`
+	Description string            `json:"description,omitempty"`
+	Header      []postmanKeyValue `json:"header"`
+	Body        *postmanBody      `json:"body,omitempty"`
+	Url         postmanUrl        `json:"url"`
+}
+
+type postmanBody struct {
+	Mode    string                 `json:"mode"`
+	Raw     string                 `json:"raw"`
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+type postmanUrl struct {
+	Raw      string            `json:"raw"`
+	Host     []string          `json:"host"`
+	Path     []string          `json:"path"`
+	Query    []postmanKeyValue `json:"query,omitempty"`
+	Variable []postmanKeyValue `json:"variable,omitempty"`
+}
+
+type postmanKeyValue struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+}
+
+type postmanResponse struct {
+	Name string `json:"name"`
+	Code int    `json:"code"`
+	Body string `json:"body,omitempty"`
+}
+
+// Generate implements IDocGenerator
+func (g *postmanGenerator) Generate(tests []IApiTest) ([]byte, error) {
+	if err := validateTestPaths(tests); err != nil {
+		return nil, err
+	}
+
+	collection := postmanCollection{
+		Info: postmanInfo{
+			Name:        g.info.Title,
+			Description: g.info.Description,
+			Version:     g.info.Version,
+			Schema:      postmanSchema,
+		},
+		Item: []postmanItem{},
+		Variable: []postmanKeyValue{
+			{Key: baseUrlVariable, Value: g.info.BaseUrl},
+		},
+	}
+
+	tags, groups := groupTestsByTag(tests)
+	for _, tag := range tags {
+		folder := postmanItem{Name: tag, Item: []postmanItem{}}
+		for _, test := range groups[tag] {
+			for _, testCase := range test.TestCases() {
+				item, err := g.generateItem(test, testCase)
+				if err != nil {
+					return nil, err
+				}
+				folder.Item = append(folder.Item, item)
+			}
+		}
+		collection.Item = append(collection.Item, folder)
+	}
+
+	return json.MarshalIndent(collection, "", "  ")
+}
+
+func (g *postmanGenerator) generateItem(test IApiTest, testCase ApiTestCase) (postmanItem, error) {
+	request := &postmanRequest{
+		Method:      test.Method(),
+		Description: test.Description(),
+		Header:      []postmanKeyValue{},
+	}
+
+	for _, name := range sortedParamNames(testCase.Headers) {
+		param := testCase.Headers[name]
+		request.Header = append(request.Header, postmanKeyValue{
+			Key:         name,
+			Value:       paramValueString(param),
+			Description: param.Description,
+		})
+	}
+
+	// postman marks path variables with a colon: /user/:username
+	tmpl := templateOf(test)
+	path := pathVariableRegexp.ReplaceAllString(tmpl.docPath(), ":$1")
+	request.Url = postmanUrl{
+		Raw:  "{{" + baseUrlVariable + "}}" + path,
+		Host: []string{"{{" + baseUrlVariable + "}}"},
+		Path: splitUrlPath(path),
+	}
+
+	pathParams, templateQuery := tmpl.splitParams(docPathParams(tmpl, testCase))
+	for _, name := range sortedParamNames(pathParams) {
+		param := pathParams[name]
+		request.Url.Variable = append(request.Url.Variable, postmanKeyValue{
+			Key:         name,
+			Value:       paramValueString(param),
+			Description: param.Description,
+		})
+	}
+
+	queryParams := ParamMap{}
+	for name, param := range templateQuery {
+		queryParams[name] = param
+	}
+	for name, param := range testCase.QueryParams {
+		queryParams[name] = param
+	}
+
+	query := []string{}
+	for _, name := range sortedParamNames(queryParams) {
+		param := queryParams[name]
+		for _, value := range paramQueryValues(param) {
+			request.Url.Query = append(request.Url.Query, postmanKeyValue{
+				Key:         name,
+				Value:       value,
+				Description: param.Description,
+			})
+			query = append(query, url.QueryEscape(name)+"="+url.QueryEscape(value))
+		}
+	}
+	if len(query) > 0 {
+		request.Url.Raw += "?" + strings.Join(query, "&")
+	}
+
+	if testCase.RequestBody != nil {
+		// TODO: right now it supports json, but should support marshaller depending on MIME type
+		content, err := json.MarshalIndent(testCase.RequestBody, "", "  ")
+		if err != nil {
+			return postmanItem{}, fmt.Errorf("could not encode request body for '%s %s': %s", test.Method(), test.Path(), err.Error())
+		}
+		request.Body = &postmanBody{
+			Mode: "raw",
+			Raw:  string(content),
+			Options: map[string]interface{}{
+				"raw": map[string]string{"language": "json"},
+			},
+		}
+	}
+
+	item := postmanItem{
+		Name:    collectionItemName(test, testCase),
+		Request: request,
+		Response: []postmanResponse{
+			{
+				Name: g.caseDescription(testCase),
+				Code: testCase.ExpectedHttpCode,
+				Body: exampleString(testCase.ExpectedData),
+			},
+		},
+	}
+
+	return item, nil
+}
+
+// collectionItemName provides a name of request in exported collections
+func collectionItemName(test IApiTest, testCase ApiTestCase) string {
+	if testCase.Description != "" {
+		return testCase.Description
+	}
+	return test.Method() + " " + test.Path()
+}
+
+func splitUrlPath(path string) []string {
+	segments := []string{}
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
 	"github.com/alecthomas/jsonschema"
@@ -12,6 +13,7 @@ import (
 )
 
 type ramlGenerator struct {
+	docOptions
 	seed raml.APIDefinition
 }
 
@@ -29,12 +31,17 @@ func NewRamlGenerator(seed raml.APIDefinition) IDocGenerator {
 }
 
 func (g *ramlGenerator) Generate(tests []IApiTest) ([]byte, error) {
+	if err := validateTestPaths(tests); err != nil {
+		return nil, err
+	}
+
 	doc := g.seed // copy seed
 
 	for _, test := range tests {
 		// path MUST begin with '/'
-		path := test.Path()
-		if path[0] != '/' {
+		tmpl := templateOf(test)
+		path := tmpl.docPath()
+		if path == "" || path[0] != '/' {
 			path = "/" + path
 		}
 
@@ -51,13 +58,19 @@ func (g *ramlGenerator) Generate(tests []IApiTest) ([]byte, error) {
 			Name:            test.Method(),
 		}
 
+		securedBy, err := ramlSecuredBy(test, doc.SecuritySchemes)
+		if err != nil {
+			return nil, err
+		}
+		m.SecuredBy = securedBy
+
 		processedHeaderParams := map[string]interface{}{}
 		processedPathParams := map[string]interface{}{}
 		processedQueryParams := map[string]interface{}{}
 
 		for _, testCase := range test.TestCases() {
 			m.Description = testCase.Description
-			for key, param := range testCase.PathParams {
+			for key, param := range docPathParams(tmpl, testCase) {
 				if _, ok := processedPathParams[key]; ok {
 					continue
 				}
@@ -65,7 +78,11 @@ func (g *ramlGenerator) Generate(tests []IApiTest) ([]byte, error) {
 				uriParam := generateRamlNamedParameter(key, param)
 
 				processedPathParams[key] = nil
-				resource.UriParameters[key] = uriParam
+				if tmpl.isQueryVar(key) {
+					m.QueryParameters[key] = uriParam
+				} else {
+					resource.UriParameters[key] = uriParam
+				}
 			}
 
 			for key, param := range testCase.Headers {
@@ -91,7 +108,7 @@ func (g *ramlGenerator) Generate(tests []IApiTest) ([]byte, error) {
 			}
 
 			response := raml.Response{}
-			response.Description = testCase.Description
+			response.Description = g.caseDescription(testCase)
 			response.HTTPCode = raml.HTTPCode(testCase.ExpectedHttpCode)
 			if testCase.ExpectedData != nil {
 				schema := jsonschema.Reflect(testCase.ExpectedData)
@@ -108,6 +125,10 @@ func (g *ramlGenerator) Generate(tests []IApiTest) ([]byte, error) {
 			m.Responses[raml.HTTPCode(testCase.ExpectedHttpCode)] = response
 
 		}
+		if isDeprecated(test) {
+			// RAML 0.8 has no deprecation flag
+			m.Description = deprecationNote(m.Description)
+		}
 
 		// TODO: check if path has already assigned an method to some other test
 		// return error if so
@@ -140,18 +161,56 @@ func (g *ramlGenerator) Generate(tests []IApiTest) ([]byte, error) {
 }
 
 func generateRamlNamedParameter(paramKey string, param Param) raml.NamedParameter {
-	return raml.NamedParameter{
+	namedParam := raml.NamedParameter{
 		Name:        paramKey,
-		Description: param.Description,
+		Description: paramDescription(param),
 		Required:    param.Required,
 		Default:     param.Value,
 		Type:        resolveRamlType(param.Value),
+		Enum:        param.Enum,
+		Example:     param.Example,
+		Minimum:     param.Minimum,
+		Maximum:     param.Maximum,
+	}
+	if _, ok := paramItems(param.Value); ok {
+		itemType := "string"
+		if sample := itemSample(param.Value); sample != nil {
+			itemType = resolveRamlType(sample)
+		}
+
+		// RAML 0.8 has no arrays: multi params are repeated,
+		// other collections are documented as delimited strings
+		if param.CollectionFormat == CollectionMulti {
+			repeat := true
+			namedParam.Repeat = &repeat
+			namedParam.Type = itemType
+		} else {
+			namedParam.Type = "string"
+			namedParam.Default = paramValueString(param)
+			namedParam.Minimum, namedParam.Maximum = nil, nil
+			namedParam.Description = strings.TrimSpace(fmt.Sprintf("%s (%s separated list of %s)",
+				namedParam.Description, collectionName(param.CollectionFormat), itemType))
+		}
+	}
+	if param.Pattern != "" {
+		pattern := param.Pattern
+		namedParam.Pattern = &pattern
 	}
+	if param.MinLength != nil {
+		minLength := int(*param.MinLength)
+		namedParam.MinLength = &minLength
+	}
+	if param.MaxLength != nil {
+		maxLength := int(*param.MaxLength)
+		namedParam.MaxLength = &maxLength
+	}
+
+	return namedParam
 }
 
 func resolveRamlType(data interface{}) string {
 	switch data.(type) {
-	case []byte:
+	case nil, []byte:
 		return "string"
 	case time.Time, *time.Time:
 		return "date"
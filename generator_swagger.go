@@ -12,6 +12,7 @@ import (
 type MarshallerFunc func(obj interface{}) ([]byte, error)
 
 type swaggerGenerator struct {
+	docOptions
 	seed       spec.Swagger
 	marshaller MarshallerFunc
 }
@@ -52,15 +53,23 @@ func NewSwaggerGenerator(seed spec.Swagger, marshaller MarshallerFunc) IDocGener
 // Generate implements IDocGenerator
 // TODO: is there any way to control swagger generator? I don't need it to analyze anonymous fields, I want to expand them
 func (g *swaggerGenerator) Generate(tests []IApiTest) ([]byte, error) {
+	if err := validateTestPaths(tests); err != nil {
+		return nil, err
+	}
+
 	doc := g.seed
 	doc.Definitions = spec.Definitions{}
 
 	for _, test := range tests {
-		path := doc.Paths.Paths[test.Path()] // TODO: 2 tests on the same API with the same response code conflict
+		docPath := templateOf(test).docPath()
+		path := doc.Paths.Paths[docPath] // TODO: 2 tests on the same API with the same response code conflict
 		op, err := g.generateSwaggerOperation(test, doc.Definitions)
 		if err != nil {
 			return nil, err
 		}
+		if op.Security, err = swaggerSecurity(test, doc.SecurityDefinitions); err != nil {
+			return nil, err
+		}
 
 		// TODO: check if path has already assigned an operation to some other test
 		// return error if so
@@ -81,7 +90,7 @@ func (g *swaggerGenerator) Generate(tests []IApiTest) ([]byte, error) {
 			path.Options = &op
 		}
 
-		doc.Paths.Paths[test.Path()] = path
+		doc.Paths.Paths[docPath] = path
 	}
 
 	d, e := g.marshaller(doc)
@@ -95,6 +104,7 @@ func (g *swaggerGenerator) generateSwaggerOperation(test IApiTest, defs spec.Def
 	op.Responses = &spec.Responses{}
 	op.Responses.StatusCodeResponses = map[int]spec.Response{}
 
+	tmpl := templateOf(test)
 	var description string
 	processedQueryParams := map[string]interface{}{}
 	processedPathParams := map[string]interface{}{}
@@ -118,12 +128,16 @@ func (g *swaggerGenerator) generateSwaggerOperation(test IApiTest, defs spec.Def
 				op.Parameters = append(op.Parameters, specParam)
 			}
 
-			for key, param := range testCase.PathParams {
+			for key, param := range docPathParams(tmpl, testCase) {
 				if _, ok := processedPathParams[key]; ok {
 					continue
 				}
-				param.Required = true // path parameters are always required
-				specParam, err := generateSwaggerSpecParam(key, param, "path")
+				location := "query" // variable of {?var} expression
+				if !tmpl.isQueryVar(key) {
+					location = "path"
+					param.Required = true // path parameters are always required
+				}
+				specParam, err := generateSwaggerSpecParam(key, param, location)
 				if err != nil {
 					return op, err
 				}
@@ -164,7 +178,7 @@ func (g *swaggerGenerator) generateSwaggerOperation(test IApiTest, defs spec.Def
 		}
 
 		response := spec.Response{}
-		response.Description = testCase.Description
+		response.Description = g.caseDescription(testCase)
 		if testCase.ExpectedData != nil {
 			response.Schema = generateSpecSchema(testCase.ExpectedData, defs)
 			response.Examples = map[string]interface{}{
@@ -176,8 +190,9 @@ func (g *swaggerGenerator) generateSwaggerOperation(test IApiTest, defs spec.Def
 	}
 
 	op.Summary = description
-	if taggable, ok := test.(ITaggable); ok {
-		op.Tags = []string{taggable.Tag()}
+	op.Deprecated = isDeprecated(test)
+	if tags := testTags(test); len(tags) > 0 {
+		op.Tags = tags
 	}
 
 	return op, nil
@@ -190,6 +205,14 @@ func generateSwaggerSpecParam(paramKey string, param Param, location string) (sp
 	specParam.Required = param.Required
 	specParam.Description = param.Description
 	specParam.Default = param.Value
+	if param.Example != nil {
+		// Swagger 2.0 has no examples of parameters
+		specParam.AddExtension("x-example", param.Example)
+	}
+	if param.Deprecated {
+		// Swagger 2.0 has no deprecation of parameters
+		specParam.AddExtension("x-deprecated", true)
+	}
 
 	paramType, err := generateSpecSimpleType(param.Value)
 	if err != nil {
@@ -197,9 +220,42 @@ func generateSwaggerSpecParam(paramKey string, param Param, location string) (sp
 	}
 	specParam.Type = paramType
 
+	if paramType != "array" {
+		specParam.Format = param.Format
+		specParam.CommonValidations = paramValidations(param)
+		return specParam, nil
+	}
+
+	// constraints of array params apply to their items
+	items := &spec.Items{}
+	items.Type, err = generateSpecSimpleType(itemSample(param.Value))
+	if err != nil {
+		return specParam, fmt.Errorf("could not guess type of items of parameter '%s': %s", paramKey, err.Error())
+	}
+	items.Format = param.Format
+	items.CommonValidations = paramValidations(param)
+	specParam.Items = items
+	specParam.CollectionFormat = param.CollectionFormat
+	if param.CollectionFormat == CollectionMulti && location != "query" && location != "formData" {
+		// Swagger allows multi for query and form params only. Repeated
+		// headers sent by the runner are the same as a csv list
+		specParam.CollectionFormat = CollectionCSV
+	}
+
 	return specParam, nil
 }
 
+func paramValidations(param Param) spec.CommonValidations {
+	return spec.CommonValidations{
+		Enum:      param.Enum,
+		Minimum:   param.Minimum,
+		Maximum:   param.Maximum,
+		Pattern:   param.Pattern,
+		MinLength: param.MinLength,
+		MaxLength: param.MaxLength,
+	}
+}
+
 func generateSpecSchema(item interface{}, defs spec.Definitions) *spec.Schema {
 	refl := jsonschema.Reflect(item)
 	schema := specSchemaFromJsonType(refl.Type)
@@ -265,6 +321,9 @@ func specSchemaFromJsonType(schema *jsonschema.Type) *spec.Schema {
 
 func generateSpecSimpleType(value interface{}) (string, error) {
 	switch value.(type) {
+	case nil:
+		// no example value, e.g. the param is provided by case setup
+		return "string", nil
 	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
 		return "integer", nil
 	case float32, float64:
@@ -274,6 +333,9 @@ func generateSpecSimpleType(value interface{}) (string, error) {
 	case string:
 		return "string", nil
 	}
+	if _, ok := paramItems(value); ok {
+		return "array", nil
+	}
 
 	return "", fmt.Errorf("value of complex type '%T' provided, simple type expected", value)
 }
@@ -0,0 +1,299 @@
+package apitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// HarRecorder is an IHttpClient decorator that records all the traffic
+// passed through it. Recorded traffic can be saved as HTTP Archive (HAR 1.2)
+// that can be loaded by browser devtools and other tools.
+//
+// Usage:
+//
+//	recorder := NewHarRecorder(&http.Client{})
+//	runner := NewRunner(baseUrl, RunnerConfig{HttpClient: recorder})
+//	runner.Run(t, tests...)
+//	if t.Failed() {
+//	    recorder.WriteFile("traffic.har")
+//	}
+type HarRecorder struct {
+	client IHttpClient
+
+	mu      sync.Mutex
+	entries []HarEntry
+}
+
+// NewHarRecorder wraps given client with a recorder
+func NewHarRecorder(client IHttpClient) *HarRecorder {
+	return &HarRecorder{client: client}
+}
+
+// Har is a root object of HTTP Archive
+type Har struct {
+	Log HarLog `json:"log"`
+}
+
+// HarLog contains all the recorded entries
+type HarLog struct {
+	Version string     `json:"version"`
+	Creator HarCreator `json:"creator"`
+	Entries []HarEntry `json:"entries"`
+}
+
+// HarEntry describes single request and its response
+type HarEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HarRequest  `json:"request"`
+	Response        HarResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HarTimings  `json:"timings"`
+	// Error contains an error returned by HTTP client, if any
+	Error string `json:"_error,omitempty"`
+}
+
+// HarCreator describes an application that produced the archive
+type HarCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HarRequest describes recorded HTTP request
+type HarRequest struct {
+	Method      string         `json:"method"`
+	Url         string         `json:"url"`
+	HttpVersion string         `json:"httpVersion"`
+	Cookies     []HarNameValue `json:"cookies"`
+	Headers     []HarNameValue `json:"headers"`
+	QueryString []HarNameValue `json:"queryString"`
+	PostData    *HarPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HarPostData contains body of recorded HTTP request
+type HarPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HarResponse describes recorded HTTP response
+type HarResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HttpVersion string         `json:"httpVersion"`
+	Cookies     []HarNameValue `json:"cookies"`
+	Headers     []HarNameValue `json:"headers"`
+	Content     HarContent     `json:"content"`
+	RedirectUrl string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HarContent contains body of recorded HTTP response
+type HarContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+// HarTimings contains timings of request/response round trip, in milliseconds
+type HarTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// HarNameValue is used to record headers, cookies and query parameters
+type HarNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Do implements IHttpClient
+func (r *HarRecorder) Do(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil {
+		var err error
+		if requestBody, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(requestBody))
+	}
+
+	started := time.Now()
+	resp, err := r.client.Do(req)
+	if err != nil {
+		r.record(newHarEntry(req, requestBody, nil, nil, started, time.Since(started), err))
+		return resp, err
+	}
+
+	var responseBody []byte
+	if resp.Body != nil {
+		responseBody, err = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+	}
+	r.record(newHarEntry(req, requestBody, resp, responseBody, started, time.Since(started), err))
+	if err != nil {
+		// the body is consumed, so the response can't be used
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// Har returns an archive with all the traffic recorded so far
+func (r *HarRecorder) Har() Har {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]HarEntry, len(r.entries))
+	copy(entries, r.entries)
+
+	return Har{
+		Log: HarLog{
+			Version: "1.2",
+			Creator: HarCreator{Name: "apitest", Version: "1.0"},
+			Entries: entries,
+		},
+	}
+}
+
+// Reset drops all recorded entries
+func (r *HarRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = nil
+}
+
+// WriteTo writes recorded archive as JSON to given writer
+func (r *HarRecorder) WriteTo(w io.Writer) (int64, error) {
+	content, err := json.MarshalIndent(r.Har(), "", "  ")
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(content)
+	return int64(n), err
+}
+
+// WriteFile saves recorded archive into a file with given name
+func (r *HarRecorder) WriteFile(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	if _, err = r.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func (r *HarRecorder) record(entry HarEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = append(r.entries, entry)
+}
+
+func newHarEntry(req *http.Request, requestBody []byte, resp *http.Response, responseBody []byte,
+	started time.Time, elapsed time.Duration, err error) HarEntry {
+
+	ms := float64(elapsed) / float64(time.Millisecond)
+	entry := HarEntry{
+		StartedDateTime: started.Format(time.RFC3339Nano),
+		Time:            ms,
+		Request: HarRequest{
+			Method:      req.Method,
+			Url:         req.URL.String(),
+			HttpVersion: req.Proto,
+			Cookies:     harCookies(req.Cookies()),
+			Headers:     harHeaders(req.Header),
+			QueryString: []HarNameValue{},
+			HeadersSize: -1,
+			BodySize:    len(requestBody),
+		},
+		Response: HarResponse{
+			Cookies:     []HarNameValue{},
+			Headers:     []HarNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Timings: HarTimings{Send: 0, Wait: ms, Receive: 0},
+	}
+
+	if entry.Request.HttpVersion == "" {
+		entry.Request.HttpVersion = "HTTP/1.1"
+	}
+
+	query := req.URL.Query()
+	for _, name := range sortedHeaderNames(http.Header(query)) {
+		for _, value := range query[name] {
+			entry.Request.QueryString = append(entry.Request.QueryString, HarNameValue{Name: name, Value: value})
+		}
+	}
+
+	if len(requestBody) > 0 {
+		entry.Request.PostData = &HarPostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     string(requestBody),
+		}
+	}
+
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	if resp != nil {
+		entry.Response.Status = resp.StatusCode
+		entry.Response.StatusText = http.StatusText(resp.StatusCode)
+		entry.Response.HttpVersion = resp.Proto
+		entry.Response.Cookies = harCookies(resp.Cookies())
+		entry.Response.Headers = harHeaders(resp.Header)
+		entry.Response.BodySize = len(responseBody)
+		entry.Response.Content = HarContent{
+			Size:     len(responseBody),
+			MimeType: resp.Header.Get("Content-Type"),
+			Text:     string(responseBody),
+		}
+		if location := resp.Header.Get("Location"); location != "" {
+			entry.Response.RedirectUrl = location
+		}
+		if entry.Response.HttpVersion == "" {
+			entry.Response.HttpVersion = "HTTP/1.1"
+		}
+	}
+
+	return entry
+}
+
+func harHeaders(header http.Header) []HarNameValue {
+	result := []HarNameValue{}
+	for _, name := range sortedHeaderNames(header) {
+		for _, value := range header[name] {
+			result = append(result, HarNameValue{Name: name, Value: value})
+		}
+	}
+	return result
+}
+
+func harCookies(cookies []*http.Cookie) []HarNameValue {
+	result := []HarNameValue{}
+	for _, cookie := range cookies {
+		result = append(result, HarNameValue{Name: cookie.Name, Value: cookie.Value})
+	}
+	return result
+}
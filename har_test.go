@@ -0,0 +1,67 @@
+package apitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHarRecorder(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	setupMock()
+
+	recorder := NewHarRecorder(&http.Client{})
+	runner := NewRunner("http://testapi.my", RunnerConfig{HttpClient: recorder})
+	runner.Run(t, &GetUserTest{}, &CreateUserTest{})
+
+	har := recorder.Har()
+	assert.Equal(t, "1.2", har.Log.Version)
+	if !assert.Len(t, har.Log.Entries, 4) {
+		return
+	}
+
+	entry := har.Log.Entries[0]
+	assert.Equal(t, "GET", entry.Request.Method)
+	assert.Equal(t, "http://testapi.my/user/octocat", entry.Request.Url)
+	assert.Contains(t, entry.Request.Headers, HarNameValue{Name: "Content-Type", Value: "application/json"})
+	assert.Equal(t, 200, entry.Response.Status)
+	assert.Contains(t, entry.Response.Content.Text, `"login":"octocat"`)
+
+	entry = har.Log.Entries[3]
+	assert.Equal(t, "POST", entry.Request.Method)
+	if assert.NotNil(t, entry.Request.PostData) {
+		assert.Contains(t, entry.Request.PostData.Text, `"login":"octocat"`)
+	}
+	assert.Equal(t, 201, entry.Response.Status)
+
+	buf := &bytes.Buffer{}
+	_, err := recorder.WriteTo(buf)
+	assert.NoError(t, err)
+
+	decoded := Har{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Len(t, decoded.Log.Entries, 4)
+}
+
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) { return 0, errors.New("connection reset") }
+
+func TestHarRecorderBodyError(t *testing.T) {
+	recorder := NewHarRecorder(IHttpClientFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(failingReader{})}, nil
+	}))
+
+	req, _ := http.NewRequest("GET", "http://testapi.my/hello", nil)
+	resp, err := recorder.Do(req)
+	assert.EqualError(t, err, "connection reset")
+	assert.Nil(t, resp)
+	assert.Len(t, recorder.Har().Log.Entries, 1, "failed exchange is still recorded")
+}
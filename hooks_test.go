@@ -0,0 +1,147 @@
+package apitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-openapi/spec"
+	"github.com/jarcoal/httpmock"
+	"github.com/seesawlabs/raml"
+	"github.com/stretchr/testify/assert"
+)
+
+type DeleteFixtureTest struct {
+	tornDown []string
+}
+
+func (t *DeleteFixtureTest) Method() string      { return "DELETE" }
+func (t *DeleteFixtureTest) Description() string { return "Test for deleting freshly created fixtures" }
+func (t *DeleteFixtureTest) Path() string        { return "/fixture/{id}" }
+func (t *DeleteFixtureTest) TestCases() []ApiTestCase {
+	return []ApiTestCase{
+		{
+			Description:      "First fixture deleted",
+			ExpectedHttpCode: 204,
+			SetUp:            t.createFixture,
+			TearDown:         t.cleanUp,
+		},
+		{
+			Description:      "Second fixture deleted",
+			ExpectedHttpCode: 204,
+			SetUp:            t.createFixture,
+			TearDown:         t.cleanUp,
+		},
+	}
+}
+
+func (t *DeleteFixtureTest) createFixture(ctx *CaseContext, testCase *ApiTestCase) error {
+	req, _ := http.NewRequest("POST", ctx.BaseUrl+"/fixture", nil)
+	resp, err := ctx.HttpClient.Do(req.WithContext(ctx.Context))
+	if err != nil {
+		return err
+	}
+
+	id := resp.Header.Get("Location")
+	testCase.PathParams = ParamMap{"id": Param{Value: id}}
+	ctx.Vars["created"] = append(ctx.Vars["created"].([]string), id)
+
+	return nil
+}
+
+func (t *DeleteFixtureTest) cleanUp(ctx *CaseContext, testCase *ApiTestCase) error {
+	t.tornDown = append(t.tornDown, testCase.PathParams["id"].Value.(string))
+	return nil
+}
+
+func TestCaseAndSuiteHooks(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	fixtures := map[string]bool{}
+	created := 0
+	httpmock.RegisterResponder("POST", "http://testapi.my/fixture",
+		func(req *http.Request) (*http.Response, error) {
+			created++
+			id := fmt.Sprintf("f%d", created)
+			fixtures[id] = true
+
+			resp := httpmock.NewBytesResponse(201, nil)
+			resp.Header.Set("Location", id)
+			return resp, nil
+		},
+	)
+	for _, id := range []string{"f1", "f2"} {
+		httpmock.RegisterResponder("DELETE", "http://testapi.my/fixture/"+id,
+			func(req *http.Request) (*http.Response, error) {
+				id := req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:]
+				if !fixtures[id] {
+					return httpmock.NewBytesResponse(404, nil), nil
+				}
+				delete(fixtures, id)
+				return httpmock.NewBytesResponse(204, nil), nil
+			},
+		)
+	}
+
+	var afterAll []string
+	runner := NewRunner("http://testapi.my", RunnerConfig{
+		BeforeAll: func(ctx *CaseContext) error {
+			ctx.Vars["created"] = []string{}
+			return nil
+		},
+		AfterAll: func(ctx *CaseContext) error {
+			afterAll = ctx.Vars["created"].([]string)
+			return nil
+		},
+	})
+
+	test := &DeleteFixtureTest{}
+	runner.Run(t, test)
+
+	assert.Equal(t, []string{"f1", "f2"}, test.tornDown)
+	assert.Equal(t, []string{"f1", "f2"}, afterAll)
+	assert.Empty(t, fixtures)
+}
+
+func TestSetUpPathParamsDocumented(t *testing.T) {
+	info := DocInfo{Title: "Fixtures", BaseUrl: "http://testapi.my"}
+	tests := []IApiTest{&DeleteFixtureTest{}}
+
+	// id is provided by case setup, so it's documented with no example value
+	for name, generator := range map[string]IDocGenerator{
+		"swagger":  NewSwaggerGeneratorJSON(spec.Swagger{}),
+		"raml":     NewRamlGenerator(raml.APIDefinition{}),
+		"markdown": NewMarkdownGenerator(info),
+		"html":     NewHtmlGenerator(info),
+		"postman":  NewPostmanGenerator(info),
+		"insomnia": NewInsomniaGenerator(info),
+	} {
+		_, err := generator.Generate(tests)
+		assert.NoError(t, err, name)
+	}
+
+	out, err := NewSwaggerGeneratorJSON(spec.Swagger{}).Generate(tests)
+	if assert.NoError(t, err) {
+		doc := spec.Swagger{}
+		assert.NoError(t, json.Unmarshal(out, &doc))
+		params := doc.Paths.Paths["/fixture/{id}"].Delete.Parameters
+		if assert.Len(t, params, 1) {
+			assert.Equal(t, "id", params[0].Name)
+			assert.Equal(t, "path", params[0].In)
+			assert.Nil(t, params[0].Default)
+		}
+	}
+
+	out, err = NewMarkdownGenerator(info).Generate(tests)
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(out), "curl -X DELETE 'http://testapi.my/fixture/{id}'")
+	}
+
+	out, err = NewInsomniaGenerator(info).Generate(tests)
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(out), "/fixture/{{ _.id }}")
+	}
+}
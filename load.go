@@ -0,0 +1,476 @@
+package apitest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ILoadWeight defines interface for tests that should be run more or less
+// often than others under load. Default weight is 1
+type ILoadWeight interface {
+	LoadWeight() int
+}
+
+// LoadConfig contains options of load run
+type LoadConfig struct {
+	// Duration of the run
+	Duration time.Duration
+	// Concurrency is a number of workers that fire requests, 1 by default
+	Concurrency int
+	// Rate is a target number of requests per second. If zero, workers
+	// fire requests as fast as they can
+	Rate float64
+}
+
+// LoadReport summarizes results of load run
+type LoadReport struct {
+	Duration time.Duration
+	Requests int
+	// Errors counts requests that failed with no response
+	Errors int
+	// Unexpected counts responses with code other than expected by test case
+	Unexpected int
+	// Dropped counts requests that were not sent at target rate
+	// because all workers were busy
+	Dropped     int
+	StatusCodes map[int]int
+	Latency     LatencyHistogram
+	Tests       []LoadTestReport
+}
+
+// LoadTestReport summarizes results of single test of load run
+type LoadTestReport struct {
+	Name        string
+	Requests    int
+	Errors      int
+	Unexpected  int
+	StatusCodes map[int]int
+	Latency     LatencyHistogram
+}
+
+// LatencyHistogram describes distribution of round trip times
+type LatencyHistogram struct {
+	Min  time.Duration
+	Mean time.Duration
+	P50  time.Duration
+	P90  time.Duration
+	P95  time.Duration
+	P99  time.Duration
+	Max  time.Duration
+
+	Buckets []LatencyBucket
+}
+
+// LatencyBucket counts requests that took no longer than UpperBound and
+// longer than UpperBound of the previous bucket. The last bucket has no bound
+type LatencyBucket struct {
+	UpperBound time.Duration
+	Count      int
+}
+
+// latencyBounds are upper bounds of histogram buckets
+var latencyBounds = []time.Duration{
+	time.Millisecond, 2 * time.Millisecond, 5 * time.Millisecond,
+	10 * time.Millisecond, 20 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 200 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2 * time.Second, 5 * time.Second,
+	time.Duration(math.MaxInt64),
+}
+
+// ErrorRate is a share of requests that failed or got unexpected response
+func (r *LoadReport) ErrorRate() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.Errors+r.Unexpected) / float64(r.Requests)
+}
+
+// WriteSummary writes human readable summary of the report
+func (r *LoadReport) WriteSummary(w io.Writer) error {
+	rps := 0.0
+	if r.Duration > 0 {
+		rps = float64(r.Requests) / r.Duration.Seconds()
+	}
+
+	lines := []string{
+		fmt.Sprintf("requests: %d in %s (%.1f/s), errors: %d, unexpected: %d, dropped: %d, error rate: %.2f%%",
+			r.Requests, r.Duration, rps, r.Errors, r.Unexpected, r.Dropped, r.ErrorRate()*100),
+		"latency: " + r.Latency.String(),
+		"status codes:",
+	}
+	for _, code := range sortedStatusCodes(r.StatusCodes) {
+		lines = append(lines, fmt.Sprintf("  %d: %d", code, r.StatusCodes[code]))
+	}
+	lines = append(lines, "histogram:")
+	for _, bucket := range r.Latency.Buckets {
+		bound := "+Inf"
+		if bucket.UpperBound != time.Duration(math.MaxInt64) {
+			bound = bucket.UpperBound.String()
+		}
+		lines = append(lines, fmt.Sprintf("  <= %s: %d", bound, bucket.Count))
+	}
+	lines = append(lines, "tests:")
+	for _, test := range r.Tests {
+		lines = append(lines, fmt.Sprintf("  %s: %d requests, errors: %d, unexpected: %d, latency: %s",
+			test.Name, test.Requests, test.Errors, test.Unexpected, test.Latency))
+	}
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h LatencyHistogram) String() string {
+	return fmt.Sprintf("min %s, mean %s, p50 %s, p90 %s, p95 %s, p99 %s, max %s",
+		h.Min, h.Mean, h.P50, h.P90, h.P95, h.P99, h.Max)
+}
+
+// newLoadTicker makes ticks of target rate of load run. Tests replace it
+// to control the rate
+var newLoadTicker = func(interval time.Duration) (<-chan time.Time, func()) {
+	ticker := time.NewTicker(interval)
+	return ticker.C, ticker.Stop
+}
+
+// Load runs cases of given tests under load using the runner's client, hooks
+// and auth. Tests are selected by filters and environment like in Run, then
+// picked randomly according to their weight, cases of a test are run in turn.
+// Skipped and pending cases are left out. Assertions of response body are not
+// run, only response code is checked. Case hooks are not run either, so cases
+// that have them are refused.
+//
+// Unlike Run, Load doesn't depend on testing.T, so it can be used in a
+// standalone program. It returns an error if the run can't be started
+func (r *httpRunner) Load(ctx context.Context, config LoadConfig, tests ...IApiTest) (*LoadReport, error) {
+	if config.Duration <= 0 {
+		return nil, fmt.Errorf("duration of load run must be positive")
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = 1
+	}
+
+	tests, err := r.selectTests(discardSink{}.Logf, tests)
+	if err != nil {
+		return nil, err
+	}
+	picker, err := newLoadPicker(tests)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, config.Duration)
+	defer cancel()
+
+	collector := newLoadCollector(tests)
+
+	wg := sync.WaitGroup{}
+
+	// with target rate, workers wait for a tick before each request.
+	// The ticker is waited for too, so all the drops are counted
+	var ticks chan struct{}
+	if config.Rate > 0 {
+		ticks = make(chan struct{})
+		interval := time.Duration(float64(time.Second) / config.Rate)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tickerC, stop := newLoadTicker(interval)
+			defer stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-tickerC:
+					select {
+					case ticks <- struct{}{}:
+					default:
+						collector.drop()
+					}
+				}
+			}
+		}()
+	}
+
+	started := time.Now()
+	for i := 0; i < config.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if ticks != nil {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticks:
+					}
+				} else if ctx.Err() != nil {
+					return
+				}
+
+				testIndex, testCase := picker.next()
+				code, duration, err := r.sendCase(ctx, tests[testIndex], testCase)
+				if err != nil && ctx.Err() != nil {
+					// the run is over, the request was interrupted
+					return
+				}
+				collector.record(testIndex, testCase, code, duration, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return collector.report(time.Since(started)), nil
+}
+
+// prepareRequest builds a request for the test case passing it through
+// request hooks and auth. Credentials set by auth are returned as well.
+// The request is bound to given context
+func (r *httpRunner) prepareRequest(ctx context.Context, test IApiTest, testCase ApiTestCase) (*http.Request, []byte, credentials, error) {
+	var creds credentials
+	req, requestBody, err := r.newRequest(testCase, test.Method(), test.Path())
+	if err != nil {
+		return nil, nil, creds, err
+	}
+	req = req.WithContext(ctx)
+	if err = r.beforeRequest(req, test, testCase); err != nil {
+		return nil, nil, creds, err
+	}
+	if auth := r.authProviderFor(test); auth != nil {
+		if creds, err = authenticate(auth, req); err != nil {
+			return nil, nil, creds, err
+		}
+	}
+
+	return req, requestBody, creds, nil
+}
+
+// sendCase sends request of the test case and returns response code and round trip time
+func (r *httpRunner) sendCase(ctx context.Context, test IApiTest, testCase ApiTestCase) (int, time.Duration, error) {
+	req, _, _, err := r.prepareRequest(ctx, test, testCase)
+	if err != nil {
+		return 0, 0, err
+	}
+	req, _, cancel := r.withTimeout(ctx, req, testCase)
+	defer cancel()
+
+	started := time.Now()
+	resp, err := r.HttpClient.Do(req)
+	if err != nil {
+		return 0, time.Since(started), err
+	}
+	if resp.Body != nil {
+		_, err = io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	return resp.StatusCode, time.Since(started), err
+}
+
+// loadPicker chooses the next case to run
+type loadPicker struct {
+	mu          sync.Mutex
+	cases       [][]ApiTestCase
+	weights     []int
+	totalWeight int
+	caseIndices []int
+}
+
+func newLoadPicker(tests []IApiTest) (*loadPicker, error) {
+	p := &loadPicker{}
+	for _, test := range tests {
+		weight := 1
+		if weighted, ok := test.(ILoadWeight); ok {
+			weight = weighted.LoadWeight()
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("test '%s' has negative load weight %d", extractTestName(test), weight)
+		}
+
+		var cases []ApiTestCase
+		for _, testCase := range test.TestCases() {
+			if testCase.skipReason() != "" {
+				continue
+			}
+			if err := validateLoadCase(test, testCase); err != nil {
+				return nil, fmt.Errorf("test '%s': %s", extractTestName(test), err.Error())
+			}
+			cases = append(cases, testCase)
+		}
+		if len(cases) == 0 {
+			weight = 0
+		}
+
+		p.cases = append(p.cases, cases)
+		p.weights = append(p.weights, weight)
+		p.totalWeight += weight
+	}
+	if p.totalWeight == 0 {
+		return nil, fmt.Errorf("no test cases to run under load")
+	}
+	p.caseIndices = make([]int, len(tests))
+
+	return p, nil
+}
+
+// validateLoadCase checks that the test case can be sent under load. Case
+// hooks are not run there, so cases that depend on them are refused
+func validateLoadCase(test IApiTest, testCase ApiTestCase) error {
+	if testCase.SetUp != nil || testCase.TearDown != nil {
+		return fmt.Errorf("case '%s' has SetUp or TearDown hooks that are not run under load", testCase.Description)
+	}
+	if err := validateCase(test, testCase); err != nil {
+		return fmt.Errorf("case '%s': %s", testCase.Description, err.Error())
+	}
+	return nil
+}
+
+// next picks a test according to weights and its next case
+func (p *loadPicker) next() (int, ApiTestCase) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n := rand.Intn(p.totalWeight)
+	testIndex := 0
+	for i, weight := range p.weights {
+		if n < weight {
+			testIndex = i
+			break
+		}
+		n -= weight
+	}
+
+	cases := p.cases[testIndex]
+	testCase := cases[p.caseIndices[testIndex]%len(cases)]
+	p.caseIndices[testIndex]++
+
+	return testIndex, testCase
+}
+
+// loadCollector accumulates results of load run
+type loadCollector struct {
+	mu        sync.Mutex
+	tests     []LoadTestReport
+	durations [][]time.Duration
+	dropped   int
+}
+
+func newLoadCollector(tests []IApiTest) *loadCollector {
+	c := &loadCollector{
+		tests:     make([]LoadTestReport, len(tests)),
+		durations: make([][]time.Duration, len(tests)),
+	}
+	for i, test := range tests {
+		c.tests[i] = LoadTestReport{Name: extractTestName(test), StatusCodes: map[int]int{}}
+	}
+	return c
+}
+
+func (c *loadCollector) record(testIndex int, testCase ApiTestCase, code int, duration time.Duration, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	test := &c.tests[testIndex]
+	test.Requests++
+	switch {
+	case err != nil:
+		test.Errors++
+	case code != testCase.ExpectedHttpCode:
+		test.Unexpected++
+	}
+	if code != 0 {
+		test.StatusCodes[code]++
+	}
+	if err == nil {
+		c.durations[testIndex] = append(c.durations[testIndex], duration)
+	}
+}
+
+func (c *loadCollector) drop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.dropped++
+}
+
+func (c *loadCollector) report(duration time.Duration) *LoadReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	report := &LoadReport{
+		Duration:    duration,
+		Dropped:     c.dropped,
+		StatusCodes: map[int]int{},
+	}
+
+	var all []time.Duration
+	for i, test := range c.tests {
+		test.Latency = newLatencyHistogram(c.durations[i])
+		report.Tests = append(report.Tests, test)
+
+		report.Requests += test.Requests
+		report.Errors += test.Errors
+		report.Unexpected += test.Unexpected
+		for code, count := range test.StatusCodes {
+			report.StatusCodes[code] += count
+		}
+		all = append(all, c.durations[i]...)
+	}
+	report.Latency = newLatencyHistogram(all)
+
+	return report
+}
+
+func newLatencyHistogram(durations []time.Duration) LatencyHistogram {
+	h := LatencyHistogram{}
+	for _, bound := range latencyBounds {
+		h.Buckets = append(h.Buckets, LatencyBucket{UpperBound: bound})
+	}
+	if len(durations) == 0 {
+		return h
+	}
+
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, duration := range sorted {
+		total += duration
+		for i := range h.Buckets {
+			if duration <= h.Buckets[i].UpperBound {
+				h.Buckets[i].Count++
+				break
+			}
+		}
+	}
+
+	h.Min = sorted[0]
+	h.Max = sorted[len(sorted)-1]
+	h.Mean = total / time.Duration(len(sorted))
+	h.P50 = percentile(sorted, 50)
+	h.P90 = percentile(sorted, 90)
+	h.P95 = percentile(sorted, 95)
+	h.P99 = percentile(sorted, 99)
+
+	return h
+}
+
+func sortedStatusCodes(codes map[int]int) []int {
+	result := make([]int, 0, len(codes))
+	for code := range codes {
+		result = append(result, code)
+	}
+	sort.Ints(result)
+	return result
+}
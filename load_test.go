@@ -0,0 +1,117 @@
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type WeightedHelloTest struct {
+	HelloTest
+	weight int
+}
+
+func (t *WeightedHelloTest) LoadWeight() int { return t.weight }
+
+func loadClient(requests *int64) IHttpClient {
+	return IHttpClientFunc(func(req *http.Request) (*http.Response, error) {
+		n := atomic.AddInt64(requests, 1)
+		code := 200
+		if req.URL.Path == "/user/octocat" || n%10 == 0 {
+			code = 500
+		}
+		return &http.Response{StatusCode: code, Body: ioutil.NopCloser(strings.NewReader("Hello World!"))}, nil
+	})
+}
+
+func TestLoad(t *testing.T) {
+	var requests int64
+	runner := NewRunner("http://testapi.my", RunnerConfig{HttpClient: loadClient(&requests)})
+
+	report, err := runner.Load(context.Background(), LoadConfig{
+		Duration:    50 * time.Millisecond,
+		Concurrency: 4,
+	}, &WeightedHelloTest{weight: 3}, &WeightedHelloTest{weight: 0}, &GetUserTest{})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, int(requests), report.Requests)
+	assert.Equal(t, report.Requests, report.StatusCodes[200]+report.StatusCodes[500])
+	assert.True(t, report.Tests[0].Requests > report.Tests[2].Requests, "weight is respected")
+	assert.Equal(t, 0, report.Tests[1].Requests)
+	assert.Equal(t, 0, report.Errors)
+	assert.True(t, report.Unexpected > 0)
+	assert.True(t, report.ErrorRate() > 0 && report.ErrorRate() < 1)
+
+	bucketed := 0
+	for _, bucket := range report.Latency.Buckets {
+		bucketed += bucket.Count
+	}
+	assert.Equal(t, report.Requests, bucketed)
+	assert.True(t, report.Latency.Min <= report.Latency.P50 && report.Latency.P50 <= report.Latency.Max)
+
+	summary := &bytes.Buffer{}
+	assert.NoError(t, report.WriteSummary(summary))
+	assert.Contains(t, summary.String(), "status codes:\n  200: ")
+	assert.Contains(t, summary.String(), "<= +Inf: 0")
+	assert.Contains(t, summary.String(), "*apitest.WeightedHelloTest: ")
+}
+
+func TestLoadRate(t *testing.T) {
+	defer func(restore func(time.Duration) (<-chan time.Time, func())) { newLoadTicker = restore }(newLoadTicker)
+
+	ticker := make(chan time.Time)
+	var interval time.Duration
+	newLoadTicker = func(d time.Duration) (<-chan time.Time, func()) {
+		interval = d
+		return ticker, func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		// the ticker is unbuffered, so the last tick is sent once all
+		// the previous ones are either taken by a worker or dropped
+		for i := 0; i < 11; i++ {
+			ticker <- time.Now()
+		}
+		cancel()
+	}()
+
+	var requests int64
+	runner := NewRunner("http://testapi.my", RunnerConfig{HttpClient: loadClient(&requests)})
+	report, err := runner.Load(ctx, LoadConfig{
+		Duration:    time.Minute,
+		Concurrency: 2,
+		Rate:        50,
+	}, &HelloTest{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, 20*time.Millisecond, interval)
+		assert.Equal(t, int(requests), report.Requests)
+		// every tick makes a request unless all the workers are busy
+		assert.Equal(t, 11, report.Requests+report.Dropped)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	runner := NewRunner("http://testapi.my", RunnerConfig{})
+
+	_, err := runner.Load(context.Background(), LoadConfig{}, &HelloTest{})
+	assert.Error(t, err)
+
+	_, err = runner.Load(context.Background(), LoadConfig{Duration: time.Second}, &WeightedHelloTest{weight: 0})
+	assert.EqualError(t, err, "no test cases to run under load")
+
+	_, err = runner.Load(context.Background(), LoadConfig{Duration: time.Second}, &DeleteFixtureTest{})
+	assert.EqualError(t, err, "test '*apitest.DeleteFixtureTest': case 'First fixture deleted' has SetUp or TearDown hooks that are not run under load")
+
+	_, err = runner.Load(context.Background(), LoadConfig{Duration: time.Second}, &SearchRepoTest{})
+	assert.EqualError(t, err, "test '*apitest.SearchRepoTest': case 'Successful search': path template '/repos/{owner}{/repo}/search{?q}' has no value for 'owner'")
+}
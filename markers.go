@@ -0,0 +1,110 @@
+package apitest
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// unstableReason tells why the case can't be relied on: it's skipped,
+// pending or known to fail. Empty for regular cases
+func (c ApiTestCase) unstableReason() string {
+	switch {
+	case c.Skip != "":
+		return "skipped: " + c.Skip
+	case c.Pending != "":
+		return "pending: " + c.Pending
+	case c.ExpectedFailure != "":
+		return "known failure: " + c.ExpectedFailure
+	}
+	return ""
+}
+
+// FlagUnstableCases returns a copy of the generator that flags skipped,
+// pending and known to fail cases in documentation with the reason. Cases
+// are documented with their plain descriptions by default. Generators that
+// can't flag cases are returned as is
+func FlagUnstableCases(generator IDocGenerator) IDocGenerator {
+	value := reflect.ValueOf(generator)
+	if _, ok := generator.(unstableFlaggable); !ok || value.Kind() != reflect.Ptr {
+		return generator
+	}
+
+	// generators keep their options in the struct, so a shallow copy is
+	// enough to leave the original one intact
+	flagged := reflect.New(value.Elem().Type())
+	flagged.Elem().Set(value.Elem())
+	flagged.Interface().(unstableFlaggable).flagUnstableCases()
+
+	return flagged.Interface().(IDocGenerator)
+}
+
+// unstableFlaggable is implemented by generators that embed docOptions
+type unstableFlaggable interface {
+	flagUnstableCases()
+}
+
+// docOptions are settings shared by generators
+type docOptions struct {
+	flagUnstable bool
+}
+
+func (o *docOptions) flagUnstableCases() {
+	o.flagUnstable = true
+}
+
+// caseDescription returns description of the case for documentation,
+// unstable cases are flagged with the reason if requested
+func (o *docOptions) caseDescription(testCase ApiTestCase) string {
+	if !o.flagUnstable {
+		return testCase.Description
+	}
+	if reason := testCase.unstableReason(); reason != "" {
+		return fmt.Sprintf("%s (unstable, %s)", testCase.Description, reason)
+	}
+	return testCase.Description
+}
+
+// skipReason tells why the case is not run: it's skipped or pending.
+// Empty for cases that must be run
+func (c ApiTestCase) skipReason() string {
+	if c.Skip != "" {
+		return c.Skip
+	}
+	if c.Pending != "" {
+		return "pending: " + c.Pending
+	}
+	return ""
+}
+
+// skipCase marks result of the case as skipped if the case is skipped
+// or pending. Returns false if the case must be run
+func skipCase(sink IAssertionSink, testCase ApiTestCase, result *CaseResult) bool {
+	reason := testCase.skipReason()
+	if reason == "" {
+		return false
+	}
+
+	sink.Logf("case '%s' is skipped: %s", testCase.Description, reason)
+	result.Passed = true
+	result.Skipped = true
+	result.Reason = reason
+	return true
+}
+
+// settleExpectedFailure turns failures of a case expected to fail into log
+// messages. The case fails if it unexpectedly passes, so fixed bugs don't
+// go unnoticed. buffered is output of the case collected by a buffered caseT
+func settleExpectedFailure(t *caseT, buffered *caseT, testCase ApiTestCase, result *CaseResult) {
+	if len(buffered.failures) == 0 {
+		t.Errorf("case '%s' is expected to fail (%s), but passed, remove ExpectedFailure if the bug is fixed",
+			testCase.Description, testCase.ExpectedFailure)
+		return
+	}
+
+	t.Logf("case '%s' failed as expected: %s", testCase.Description, testCase.ExpectedFailure)
+	for _, output := range buffered.output {
+		t.Logf("%s", output.message)
+	}
+	result.KnownFailure = true
+	result.Reason = testCase.ExpectedFailure
+}
@@ -0,0 +1,131 @@
+package apitest
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type UnstableHelloTest struct {
+	HelloTest
+}
+
+func (t *UnstableHelloTest) TestCases() []ApiTestCase {
+	return []ApiTestCase{
+		{
+			Description:      "Greeting in french",
+			ExpectedHttpCode: 200,
+			ExpectedData:     "Bonjour le monde!",
+			Skip:             "translations are disabled",
+		},
+		{
+			Description:      "Greeting of mars",
+			ExpectedHttpCode: 200,
+			Pending:          "not implemented",
+		},
+		{
+			Description:      "Greeting of gophers",
+			ExpectedHttpCode: 200,
+			ExpectedData:     "Hello Gophers!",
+			ExpectedFailure:  "issue #12",
+		},
+		{
+			Description:      "Greeting of the world",
+			ExpectedHttpCode: 200,
+			ExpectedData:     "Hello World!",
+			ExpectedFailure:  "issue #13",
+		},
+	}
+}
+
+func TestUnstableCases(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	setupMock()
+
+	runner := NewRunner("http://testapi.my", RunnerConfig{})
+	report, err := runner.RunSuite(context.Background(), []IApiTest{&UnstableHelloTest{}})
+	assert.NoError(t, err)
+	if !assert.Len(t, report.Tests, 1) || !assert.Len(t, report.Tests[0].Cases, 4) {
+		return
+	}
+
+	cases := report.Tests[0].Cases
+	assert.True(t, cases[0].Passed)
+	assert.True(t, cases[0].Skipped)
+	assert.Equal(t, "translations are disabled", cases[0].Reason)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount(), "skipped and pending cases are not run")
+
+	assert.True(t, cases[1].Skipped)
+	assert.Equal(t, "pending: not implemented", cases[1].Reason)
+
+	assert.True(t, cases[2].Passed)
+	assert.True(t, cases[2].KnownFailure)
+	assert.Empty(t, cases[2].Failures)
+
+	assert.False(t, cases[3].Passed, "unexpected pass is a failure")
+	assert.Equal(t, []string{"case 'Greeting of the world' is expected to fail (issue #13), but passed, remove ExpectedFailure if the bug is fixed"},
+		cases[3].Failures)
+}
+
+func TestUnstableCasesDocumented(t *testing.T) {
+	plain, err := NewMarkdownGenerator(DocInfo{Title: "Hello"}).Generate([]IApiTest{&UnstableHelloTest{}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, string(plain), "##### 200: Greeting in french\n")
+	assert.NotContains(t, string(plain), "unstable")
+
+	generator := NewMarkdownGenerator(DocInfo{Title: "Hello"})
+	doc, err := FlagUnstableCases(generator).Generate([]IApiTest{&UnstableHelloTest{}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	again, err := generator.Generate([]IApiTest{&UnstableHelloTest{}})
+	if assert.NoError(t, err) {
+		assert.Equal(t, string(plain), string(again), "original generator is left intact")
+	}
+
+	content := string(doc)
+	assert.Contains(t, content, "##### 200: Greeting in french (unstable, skipped: translations are disabled)")
+	assert.Contains(t, content, "##### 200: Greeting of mars (unstable, pending: not implemented)")
+	assert.Contains(t, content, "##### 200: Greeting of gophers (unstable, known failure: issue #12)")
+	assert.Equal(t, 4, strings.Count(content, "##### "))
+}
+
+func TestUnstableCasesInsomnia(t *testing.T) {
+	doc, err := FlagUnstableCases(NewInsomniaGenerator(DocInfo{Title: "Hello"})).Generate([]IApiTest{&UnstableHelloTest{}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	content := string(doc)
+	assert.Contains(t, content, `"name": "Greeting in french (unstable, skipped: translations are disabled)"`)
+	assert.Contains(t, content, `"name": "Greeting of the world (unstable, known failure: issue #13)"`)
+}
+
+type SkippedCreatePostTest struct {
+	CreatePostTest
+}
+
+func (t *SkippedCreatePostTest) TestCases() []ApiTestCase {
+	cases := t.CreatePostTest.TestCases()
+	cases[0].Pending = "posts are not implemented"
+	return cases
+}
+
+func TestUnstableCasesLoadAndFuzz(t *testing.T) {
+	picker, err := newLoadPicker([]IApiTest{&UnstableHelloTest{}})
+	if assert.NoError(t, err) && assert.Len(t, picker.cases[0], 2, "skipped and pending cases are not run under load") {
+		assert.Equal(t, "Greeting of gophers", picker.cases[0][0].Description)
+	}
+
+	var requests int64
+	runner := NewRunner("http://testapi.my", RunnerConfig{HttpClient: loadClient(&requests)})
+	assert.Empty(t, runner.fuzzTest(&SkippedCreatePostTest{}, 10, rand.New(rand.NewSource(1))))
+	assert.Equal(t, int64(0), requests, "pending cases are not fuzzed")
+}
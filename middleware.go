@@ -0,0 +1,60 @@
+package apitest
+
+import "net/http"
+
+// BeforeRequestFunc is called right before a request of the test case is sent.
+// It may modify the request: sign it, add some headers and so on.
+// Returning an error fails the test case.
+type BeforeRequestFunc func(req *http.Request, test IApiTest, testCase ApiTestCase) error
+
+// AfterResponseFunc is called right after a response to the test case is received
+// and before it is asserted. It may modify the response and return modified body.
+// Returning an error fails the test case.
+type AfterResponseFunc func(resp *http.Response, body []byte, test IApiTest, testCase ApiTestCase) ([]byte, error)
+
+// IRequestModifier defines interface for tests that need to modify requests
+// of their cases before they are sent. Called after RunnerConfig.BeforeRequest hooks
+type IRequestModifier interface {
+	ModifyRequest(req *http.Request, testCase ApiTestCase) error
+}
+
+// IResponseModifier defines interface for tests that need to modify responses
+// to their cases before they are asserted. Called before RunnerConfig.AfterResponse hooks
+type IResponseModifier interface {
+	ModifyResponse(resp *http.Response, body []byte, testCase ApiTestCase) ([]byte, error)
+}
+
+// beforeRequest runs the chain of request hooks: runner-wide ones first,
+// then the test's own modifier
+func (r *httpRunner) beforeRequest(req *http.Request, test IApiTest, testCase ApiTestCase) error {
+	for _, hook := range r.BeforeRequest {
+		if err := hook(req, test, testCase); err != nil {
+			return err
+		}
+	}
+
+	if modifier, ok := test.(IRequestModifier); ok {
+		return modifier.ModifyRequest(req, testCase)
+	}
+
+	return nil
+}
+
+// afterResponse runs the chain of response hooks: the test's own modifier
+// first, then runner-wide ones
+func (r *httpRunner) afterResponse(resp *http.Response, body []byte, test IApiTest, testCase ApiTestCase) ([]byte, error) {
+	var err error
+	if modifier, ok := test.(IResponseModifier); ok {
+		if body, err = modifier.ModifyResponse(resp, body, testCase); err != nil {
+			return body, err
+		}
+	}
+
+	for _, hook := range r.AfterResponse {
+		if body, err = hook(resp, body, test, testCase); err != nil {
+			return body, err
+		}
+	}
+
+	return body, nil
+}
@@ -0,0 +1,115 @@
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type SignedHelloTest struct {
+	HelloTest
+}
+
+func (t *SignedHelloTest) ModifyRequest(req *http.Request, testCase ApiTestCase) error {
+	req.Header.Set("X-Signature", "signed:"+req.Header.Get("X-Trace-Id"))
+	return nil
+}
+
+func (t *SignedHelloTest) ModifyResponse(resp *http.Response, body []byte, testCase ApiTestCase) ([]byte, error) {
+	return append(body, " (test)"...), nil
+}
+
+func (t *SignedHelloTest) TestCases() []ApiTestCase {
+	return []ApiTestCase{
+		{
+			Description:      "Greeting with signed request",
+			ExpectedHttpCode: 200,
+			ExpectedData:     "Hello World! (test) (runner)",
+		},
+	}
+}
+
+func TestRequestResponseHooks(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://testapi.my/hello",
+		func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("X-Signature") != "signed:trace-1" {
+				return httpmock.NewStringResponse(401, "unsigned"), nil
+			}
+			return httpmock.NewStringResponse(200, "Hello World!"), nil
+		},
+	)
+
+	var hookedTest IApiTest
+	runner := NewRunner("http://testapi.my", RunnerConfig{
+		BeforeRequest: []BeforeRequestFunc{
+			func(req *http.Request, test IApiTest, testCase ApiTestCase) error {
+				hookedTest = test
+				req.Header.Set("X-Trace-Id", "trace-1")
+				return nil
+			},
+		},
+		AfterResponse: []AfterResponseFunc{
+			func(resp *http.Response, body []byte, test IApiTest, testCase ApiTestCase) ([]byte, error) {
+				return append(body, " (runner)"...), nil
+			},
+		},
+	})
+
+	test := &SignedHelloTest{}
+	runner.Run(t, test)
+	assert.Equal(t, test, hookedTest)
+}
+
+type EchoTest struct{}
+
+func (t *EchoTest) Method() string      { return "POST" }
+func (t *EchoTest) Description() string { return "Test for echo API handler" }
+func (t *EchoTest) Path() string        { return "/echo" }
+func (t *EchoTest) TestCases() []ApiTestCase {
+	return []ApiTestCase{
+		{
+			Description:      "Echo of the body",
+			RequestBody:      map[string]bool{"original": true},
+			ExpectedHttpCode: 200,
+		},
+	}
+}
+
+func TestRequestHookBodyReproduced(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var received []byte
+	httpmock.RegisterResponder("POST", "http://testapi.my/echo",
+		func(req *http.Request) (*http.Response, error) {
+			received, _ = ioutil.ReadAll(req.Body)
+			return httpmock.NewStringResponse(500, ""), nil
+		},
+	)
+
+	logs := &bytes.Buffer{}
+	runner := NewRunner("http://testapi.my", RunnerConfig{
+		Sink: LogSink(log.New(logs, "", 0)),
+		BeforeRequest: []BeforeRequestFunc{
+			func(req *http.Request, test IApiTest, testCase ApiTestCase) error {
+				req.Body = ioutil.NopCloser(strings.NewReader(`{"rewritten":true}`))
+				return nil
+			},
+		},
+	})
+	_, err := runner.RunSuite(context.Background(), []IApiTest{&EchoTest{}})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"rewritten":true}`, string(received))
+	assert.Contains(t, logs.String(), `-d '{"rewritten":true}'`)
+	assert.NotContains(t, logs.String(), "original")
+}
@@ -0,0 +1,352 @@
+package apitest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MonitorConfig contains options of synthetic monitoring
+type MonitorConfig struct {
+	// Schedule is a cron expression: 5 fields (minute, hour, day of month,
+	// month, day of week), an alias like @hourly or '@every 30s'
+	Schedule string
+	// BaseUrls are APIs the tests are run against, one after another
+	BaseUrls []string
+	// Filter selects tests to run. All tests are run if empty
+	Filter TestFilter
+	// Runner configures runners of the monitor. Its Sink also receives
+	// errors of scheduled runs. Its Reporters are shared by all the runs
+	// against all the base URLs, use NewReporters to get separate ones
+	Runner RunnerConfig
+	// NewReporters builds reporters for a run against given base URL, e.g.
+	// writing to a file per base URL. Runner.Reporters are used if nil
+	NewReporters func(baseUrl string) []IReporter
+
+	// MetricsAddr is an address metrics are served on in Prometheus format,
+	// e.g. "127.0.0.1:9090". Metrics are not served if empty, the monitor
+	// can still be mounted as http.Handler
+	MetricsAddr string
+
+	// HistorySize is a number of recent runs kept, 100 by default
+	HistorySize int
+	// HistoryFile is a file recent runs are written to as JSON lines
+	// after each run. Only last HistorySize runs are kept in the file
+	HistoryFile string
+}
+
+// MonitorRun describes single run of monitored tests against a base URL
+type MonitorRun struct {
+	BaseUrl  string
+	Started  time.Time
+	Duration time.Duration
+	Report   *Report
+	// Error is an error that didn't let the run complete, if any
+	Error error
+}
+
+// Passed tells whether the run completed and all the tests passed
+func (r MonitorRun) Passed() bool {
+	return r.Error == nil && r.Report.Passed()
+}
+
+// Monitor runs tests on a schedule, collecting metrics and history of results
+type Monitor struct {
+	config   MonitorConfig
+	schedule *cronSchedule
+	tests    []IApiTest
+
+	mu      sync.Mutex
+	history []MonitorRun
+	// counters of runs by base URL and result
+	runs map[string]map[bool]int
+	// errors counts scheduled runs that failed with an error
+	errors int
+}
+
+// NewMonitor creates a monitor for given tests. Fails if the schedule
+// can't be parsed or no tests match the filter
+func NewMonitor(config MonitorConfig, tests ...IApiTest) (*Monitor, error) {
+	schedule, err := parseCronSchedule(config.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	if len(config.BaseUrls) == 0 {
+		return nil, fmt.Errorf("no base URLs to monitor")
+	}
+	if config.HistorySize <= 0 {
+		config.HistorySize = 100
+	}
+
+	if err = config.Filter.Validate(); err != nil {
+		return nil, err
+	}
+	var selected []IApiTest
+	for _, test := range tests {
+		if config.Filter.Match(test) {
+			selected = append(selected, test)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no tests match the filter")
+	}
+
+	return &Monitor{
+		config:   config,
+		schedule: schedule,
+		tests:    selected,
+		runs:     map[string]map[bool]int{},
+	}, nil
+}
+
+// newMonitorTimer creates a timer that fires when the next scheduled run is
+// due, tests replace it to control the schedule
+var newMonitorTimer = func(d time.Duration) (<-chan time.Time, func()) {
+	timer := time.NewTimer(d)
+	return timer.C, func() { timer.Stop() }
+}
+
+// Run runs tests on the schedule until the context is cancelled. Metrics are
+// served on MetricsAddr while the monitor is running. Errors of runs don't
+// stop the schedule, they are passed to the sink of the runner and counted
+func (m *Monitor) Run(ctx context.Context) error {
+	if m.config.MetricsAddr != "" {
+		listener, err := net.Listen("tcp", m.config.MetricsAddr)
+		if err != nil {
+			return fmt.Errorf("could not serve metrics: %s", err.Error())
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", m)
+		server := &http.Server{Handler: mux}
+		go server.Serve(listener)
+		defer server.Close()
+	}
+
+	for {
+		next := m.schedule.Next(time.Now())
+		if next.IsZero() {
+			return fmt.Errorf("schedule '%s' is never due", m.config.Schedule)
+		}
+
+		timerC, stop := newMonitorTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			stop()
+			return nil
+		case <-timerC:
+		}
+
+		if _, err := m.RunOnce(ctx); err != nil {
+			m.recordError(err)
+		}
+	}
+}
+
+// recordError counts and logs an error of scheduled run
+func (m *Monitor) recordError(err error) {
+	m.mu.Lock()
+	m.errors++
+	m.mu.Unlock()
+
+	if m.config.Runner.Sink != nil {
+		m.config.Runner.Sink.Errorf("monitor run failed: %s", err.Error())
+	}
+}
+
+// RunOnce runs the tests against all the base URLs right away. Results are
+// recorded into metrics and history. An error is returned if the history
+// can't be written
+func (m *Monitor) RunOnce(ctx context.Context) ([]MonitorRun, error) {
+	var runs []MonitorRun
+	for _, baseUrl := range m.config.BaseUrls {
+		run := MonitorRun{BaseUrl: baseUrl, Started: time.Now()}
+		config := m.config.Runner
+		if m.config.NewReporters != nil {
+			config.Reporters = m.config.NewReporters(baseUrl)
+		}
+		run.Report, run.Error = NewRunner(baseUrl, config).RunSuite(ctx, m.tests)
+		run.Duration = time.Since(run.Started)
+
+		runs = append(runs, run)
+		m.record(run)
+	}
+
+	return runs, m.writeHistory()
+}
+
+func (m *Monitor) record(run MonitorRun) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.history = append(m.history, run)
+	if len(m.history) > m.config.HistorySize {
+		m.history = m.history[len(m.history)-m.config.HistorySize:]
+	}
+
+	if m.runs[run.BaseUrl] == nil {
+		m.runs[run.BaseUrl] = map[bool]int{}
+	}
+	m.runs[run.BaseUrl][run.Passed()]++
+}
+
+// History returns recent runs, the oldest first
+func (m *Monitor) History() []MonitorRun {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	history := make([]MonitorRun, len(m.history))
+	copy(history, m.history)
+	return history
+}
+
+// monitorHistoryEntry is a JSON representation of MonitorRun
+type monitorHistoryEntry struct {
+	BaseUrl    string           `json:"base_url"`
+	Started    time.Time        `json:"started"`
+	DurationMs float64          `json:"duration_ms"`
+	Passed     bool             `json:"passed"`
+	Error      string           `json:"error,omitempty"`
+	Errors     []string         `json:"errors,omitempty"`
+	Tests      []jsonTestReport `json:"tests"`
+}
+
+// writeHistory rewrites history file with recent runs. The file is replaced
+// atomically, so readers never see partially written history
+func (m *Monitor) writeHistory() error {
+	if m.config.HistoryFile == "" {
+		return nil
+	}
+
+	lines := []string{}
+	for _, run := range m.History() {
+		entry := monitorHistoryEntry{
+			BaseUrl:    run.BaseUrl,
+			Started:    run.Started,
+			DurationMs: float64(run.Duration) / float64(time.Millisecond),
+			Passed:     run.Passed(),
+			Errors:     run.Report.Errors,
+			Tests:      newJsonTestReports(run.Report.Tests),
+		}
+		if run.Error != nil {
+			entry.Error = run.Error.Error()
+		}
+
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, string(line))
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(m.config.HistoryFile), ".apitest-history")
+	if err != nil {
+		return fmt.Errorf("could not write history: %s", err.Error())
+	}
+	if _, err = tmp.WriteString(strings.Join(lines, "\n") + "\n"); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("could not write history: %s", err.Error())
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("could not write history: %s", err.Error())
+	}
+
+	return os.Rename(tmp.Name(), m.config.HistoryFile)
+}
+
+// ServeHTTP serves metrics of the last runs in Prometheus text format
+func (m *Monitor) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write([]byte(m.metrics()))
+}
+
+func (m *Monitor) metrics() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// only the last run against each base URL matters for gauges
+	last := map[string]MonitorRun{}
+	for _, run := range m.history {
+		last[run.BaseUrl] = run
+	}
+	baseUrls := make([]string, 0, len(last))
+	for baseUrl := range last {
+		baseUrls = append(baseUrls, baseUrl)
+	}
+	sort.Strings(baseUrls)
+
+	lines := []string{
+		"# HELP apitest_runs_total Number of monitor runs by result",
+		"# TYPE apitest_runs_total counter",
+	}
+	for _, baseUrl := range baseUrls {
+		for _, passed := range []bool{true, false} {
+			result := "failed"
+			if passed {
+				result = "passed"
+			}
+			lines = append(lines, fmt.Sprintf("apitest_runs_total{base_url=%s,result=%s} %d",
+				promLabel(baseUrl), promLabel(result), m.runs[baseUrl][passed]))
+		}
+	}
+
+	lines = append(lines,
+		"# HELP apitest_monitor_errors_total Number of scheduled runs failed with an error",
+		"# TYPE apitest_monitor_errors_total counter",
+		fmt.Sprintf("apitest_monitor_errors_total %d", m.errors),
+		"# HELP apitest_last_run_timestamp_seconds Time the last run started at",
+		"# TYPE apitest_last_run_timestamp_seconds gauge",
+	)
+	for _, baseUrl := range baseUrls {
+		lines = append(lines, fmt.Sprintf("apitest_last_run_timestamp_seconds{base_url=%s} %d",
+			promLabel(baseUrl), last[baseUrl].Started.Unix()))
+	}
+
+	passed := []string{
+		"# HELP apitest_case_passed Whether the test case passed in the last run",
+		"# TYPE apitest_case_passed gauge",
+	}
+	durations := []string{
+		"# HELP apitest_case_duration_seconds Round trip time of the test case in the last run",
+		"# TYPE apitest_case_duration_seconds gauge",
+	}
+	for _, baseUrl := range baseUrls {
+		for _, test := range last[baseUrl].Report.Tests {
+			for i, result := range test.Cases {
+				labels := fmt.Sprintf("{base_url=%s,test=%s,case=%s}",
+					promLabel(baseUrl), promLabel(test.Name), promLabel(caseName(result, i)))
+
+				value := 0
+				if result.Passed {
+					value = 1
+				}
+				passed = append(passed, fmt.Sprintf("apitest_case_passed%s %d", labels, value))
+				durations = append(durations, fmt.Sprintf("apitest_case_duration_seconds%s %g",
+					labels, result.Duration.Seconds()))
+			}
+		}
+	}
+	lines = append(lines, passed...)
+	lines = append(lines, durations...)
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// promLabel quotes value of Prometheus label
+func promLabel(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	value = strings.Replace(value, "\n", `\n`, -1)
+	value = strings.Replace(value, `"`, `\"`, -1)
+	return `"` + value + `"`
+}
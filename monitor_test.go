@@ -0,0 +1,171 @@
+package apitest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type TaggedHelloTest struct {
+	HelloTest
+}
+
+func (t *TaggedHelloTest) Tag() string { return "smoke" }
+
+func TestCronSchedule(t *testing.T) {
+	at := time.Date(2016, time.February, 28, 23, 58, 30, 0, time.UTC)
+	for spec, expected := range map[string]time.Time{
+		"* * * * *":        time.Date(2016, time.February, 28, 23, 59, 0, 0, time.UTC),
+		"*/15 * * * *":     time.Date(2016, time.February, 29, 0, 0, 0, 0, time.UTC),
+		"30 9-17/4 * * *":  time.Date(2016, time.February, 29, 9, 30, 0, 0, time.UTC),
+		"0 0 1 * *":        time.Date(2016, time.March, 1, 0, 0, 0, 0, time.UTC),
+		"0 12 * * 7":       time.Date(2016, time.March, 6, 12, 0, 0, 0, time.UTC),
+		"0 12 13 * 5":      time.Date(2016, time.March, 4, 12, 0, 0, 0, time.UTC),
+		"0 0 29 2 *":       time.Date(2016, time.February, 29, 0, 0, 0, 0, time.UTC),
+		"@hourly":          time.Date(2016, time.February, 29, 0, 0, 0, 0, time.UTC),
+		"@every 1m30s":     at.Add(90 * time.Second),
+		"5,10 0 * jan-feb": {},
+	} {
+		schedule, err := parseCronSchedule(spec)
+		if expected.IsZero() {
+			assert.Error(t, err, spec)
+			continue
+		}
+		if assert.NoError(t, err, spec) {
+			assert.Equal(t, expected, schedule.Next(at), spec)
+		}
+	}
+
+	for _, spec := range []string{"* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "@every -1s"} {
+		_, err := parseCronSchedule(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestMonitor(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	setupMock()
+	httpmock.RegisterResponder("GET", "http://staging.testapi.my/hello",
+		httpmock.NewStringResponder(503, "maintenance"))
+
+	dir, err := ioutil.TempDir("", "apitest-monitor")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	historyFile := filepath.Join(dir, "history.jsonl")
+
+	monitor, err := NewMonitor(MonitorConfig{
+		Schedule:    "@every 1m",
+		BaseUrls:    []string{"http://testapi.my", "http://staging.testapi.my"},
+		Filter:      TestFilter{Tags: "smoke"},
+		HistorySize: 3,
+		HistoryFile: historyFile,
+	}, &TaggedHelloTest{}, &GetUserTest{})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for i := 0; i < 2; i++ {
+		runs, err := monitor.RunOnce(context.Background())
+		assert.NoError(t, err)
+		if assert.Len(t, runs, 2) {
+			assert.True(t, runs[0].Passed())
+			assert.False(t, runs[1].Passed())
+			assert.Len(t, runs[0].Report.Tests, 1, "only tagged tests are run")
+		}
+	}
+	assert.Len(t, monitor.History(), 3)
+
+	recorder := httptest.NewRecorder()
+	monitor.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	metrics := recorder.Body.String()
+	assert.Contains(t, metrics, `apitest_runs_total{base_url="http://testapi.my",result="passed"} 2`)
+	assert.Contains(t, metrics, `apitest_runs_total{base_url="http://staging.testapi.my",result="failed"} 2`)
+	assert.Contains(t, metrics, `apitest_case_passed{base_url="http://staging.testapi.my",test="*apitest.TaggedHelloTest",case="Successful greeting of the world"} 0`)
+	assert.Contains(t, metrics, "# TYPE apitest_case_duration_seconds gauge\n")
+
+	f, err := os.Open(historyFile)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer f.Close()
+
+	entries := []monitorHistoryEntry{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		entry := monitorHistoryEntry{}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	if assert.Len(t, entries, 3, "history is rolled") {
+		assert.Equal(t, "http://staging.testapi.my", entries[0].BaseUrl)
+		assert.Equal(t, 503, entries[2].Tests[0].Cases[0].HttpCode)
+	}
+
+	_, err = NewMonitor(MonitorConfig{Schedule: "@daily", BaseUrls: []string{"http://testapi.my"}, Filter: TestFilter{Tags: "none"}},
+		&TaggedHelloTest{})
+	assert.EqualError(t, err, "no tests match the filter")
+}
+
+func TestMonitorRunSurvivesErrors(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	setupMock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the schedule is due 3 times, then the monitor is stopped
+	defer func(restore func(time.Duration) (<-chan time.Time, func())) { newMonitorTimer = restore }(newMonitorTimer)
+	timers := 0
+	newMonitorTimer = func(time.Duration) (<-chan time.Time, func()) {
+		fired := make(chan time.Time, 1)
+		if timers++; timers <= 3 {
+			fired <- time.Now()
+		} else {
+			cancel()
+		}
+		return fired, func() {}
+	}
+
+	logs := &bytes.Buffer{}
+	reporters := map[string]*resultCollector{}
+	monitor, err := NewMonitor(MonitorConfig{
+		Schedule: "@every 1h",
+		BaseUrls: []string{"http://testapi.my"},
+		Runner:   RunnerConfig{Sink: LogSink(log.New(logs, "", 0))},
+		NewReporters: func(baseUrl string) []IReporter {
+			reporters[baseUrl] = &resultCollector{}
+			return []IReporter{reporters[baseUrl]}
+		},
+		// history can't be written into missing directory
+		HistoryFile: filepath.Join(t.TempDir(), "missing", "history.jsonl"),
+	}, &HelloTest{})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, monitor.Run(ctx))
+
+	assert.Len(t, monitor.History(), 3, "the schedule goes on after errors")
+	assert.Contains(t, logs.String(), "monitor run failed: could not write history")
+	assert.Len(t, reporters["http://testapi.my"].flush(), 1, "reporters are built per run")
+
+	recorder := httptest.NewRecorder()
+	monitor.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Contains(t, recorder.Body.String(), "apitest_monitor_errors_total 3\n")
+	assert.Contains(t, recorder.Body.String(), "# TYPE apitest_monitor_errors_total counter\n")
+}
@@ -0,0 +1,278 @@
+package apitest
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Collection formats of array params, see Param.CollectionFormat
+const (
+	CollectionCSV   = "csv"
+	CollectionSSV   = "ssv"
+	CollectionTSV   = "tsv"
+	CollectionPipes = "pipes"
+	CollectionMulti = "multi"
+)
+
+// uuidPattern matches UUIDs of any version
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// paramGroup is a set of params of the same kind
+type paramGroup struct {
+	kind   string
+	params ParamMap
+}
+
+// paramGroups returns params of the test case grouped by kind, in stable order
+func paramGroups(testCase ApiTestCase) []paramGroup {
+	return []paramGroup{
+		{paramKindHeader, testCase.Headers},
+		{paramKindQuery, testCase.QueryParams},
+		{paramKindPath, testCase.PathParams},
+	}
+}
+
+// paramExample returns a value of the param shown in documentation
+func paramExample(param Param) Param {
+	if param.Example != nil {
+		param.Value = param.Example
+	}
+	return param
+}
+
+// validateParams checks values of all the params of the test case against
+// their constraints
+func validateParams(testCase ApiTestCase) error {
+	for _, group := range paramGroups(testCase) {
+		for _, name := range sortedParamNames(group.params) {
+			if err := validateParam(group.params[name]); err != nil {
+				return fmt.Errorf("invalid %s param '%s': %s", group.kind, name, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// validateParam checks that value of the param satisfies its constraints.
+// Constraints of slice valued params apply to each item
+func validateParam(param Param) error {
+	switch param.CollectionFormat {
+	case "", CollectionCSV, CollectionSSV, CollectionTSV, CollectionPipes, CollectionMulti:
+	default:
+		return fmt.Errorf("unknown collection format '%s'", param.CollectionFormat)
+	}
+
+	items, ok := paramItems(param.Value)
+	if !ok {
+		return validateParamValue(param, param.Value)
+	}
+	for i, item := range items {
+		if err := validateParamValue(param, item); err != nil {
+			return fmt.Errorf("item %d: %s", i, err.Error())
+		}
+	}
+	return nil
+}
+
+func validateParamValue(param Param, rawValue interface{}) error {
+	if rawValue == nil {
+		return nil
+	}
+	value := valueString(rawValue)
+
+	if len(param.Enum) > 0 {
+		allowed := make([]string, 0, len(param.Enum))
+		for _, item := range param.Enum {
+			allowed = append(allowed, valueString(item))
+		}
+		if !containsString(allowed, value) {
+			return fmt.Errorf("value '%s' is not one of %s", value, strings.Join(allowed, ", "))
+		}
+	}
+
+	if param.Minimum != nil || param.Maximum != nil {
+		number, err := paramNumber(rawValue)
+		if err != nil {
+			return err
+		}
+		if param.Minimum != nil && number < *param.Minimum {
+			return fmt.Errorf("value %s is less than minimum %g", value, *param.Minimum)
+		}
+		if param.Maximum != nil && number > *param.Maximum {
+			return fmt.Errorf("value %s is greater than maximum %g", value, *param.Maximum)
+		}
+	}
+
+	length := int64(utf8.RuneCountInString(value))
+	if param.MinLength != nil && length < *param.MinLength {
+		return fmt.Errorf("value '%s' is shorter than %d characters", value, *param.MinLength)
+	}
+	if param.MaxLength != nil && length > *param.MaxLength {
+		return fmt.Errorf("value '%s' is longer than %d characters", value, *param.MaxLength)
+	}
+
+	if param.Pattern != "" {
+		pattern, err := regexp.Compile(param.Pattern)
+		if err != nil {
+			return fmt.Errorf("could not compile pattern '%s': %s", param.Pattern, err.Error())
+		}
+		if !pattern.MatchString(value) {
+			return fmt.Errorf("value '%s' does not match pattern '%s'", value, param.Pattern)
+		}
+	}
+
+	if !matchesFormat(param.Format, value) {
+		return fmt.Errorf("value '%s' is not of format '%s'", value, param.Format)
+	}
+
+	return nil
+}
+
+// paramItems returns items of slice valued param. Byte slices are not
+// considered collections
+func paramItems(value interface{}) ([]interface{}, bool) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, false
+	}
+	if v.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, false
+	}
+
+	items := make([]interface{}, v.Len())
+	for i := range items {
+		items[i] = v.Index(i).Interface()
+	}
+	return items, true
+}
+
+// itemSample returns a value of the type of items of slice value,
+// so the type can be documented. Returns nil if it can't be guessed
+func itemSample(value interface{}) interface{} {
+	elemType := reflect.TypeOf(value).Elem()
+	if elemType.Kind() != reflect.Interface {
+		return reflect.Zero(elemType).Interface()
+	}
+	if items, _ := paramItems(value); len(items) > 0 {
+		return items[0]
+	}
+	return nil
+}
+
+func itemStrings(items []interface{}) []string {
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		result = append(result, valueString(item))
+	}
+	return result
+}
+
+// collectionSeparator returns separator of items of given collection format
+func collectionSeparator(format string) string {
+	switch format {
+	case CollectionSSV:
+		return " "
+	case CollectionTSV:
+		return "\t"
+	case CollectionPipes:
+		return "|"
+	}
+	return ","
+}
+
+// collectionName describes delimiter of collection format in docs
+func collectionName(format string) string {
+	switch format {
+	case CollectionSSV:
+		return "space"
+	case CollectionTSV:
+		return "tab"
+	case CollectionPipes:
+		return "pipe"
+	}
+	return "comma"
+}
+
+// paramQueryValues returns values the param is sent with in URL query.
+// 'multi' params are sent as repeated keys, e.g. '?id=1&id=2'
+func paramQueryValues(param Param) []string {
+	if items, ok := paramItems(param.Value); ok && param.CollectionFormat == CollectionMulti {
+		return itemStrings(items)
+	}
+	return []string{paramValueString(param)}
+}
+
+// paramNumber converts numeric value of a param to float64
+func paramNumber(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), nil
+	case int8:
+		return float64(v), nil
+	case int16:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint:
+		return float64(v), nil
+	case uint8:
+		return float64(v), nil
+	case uint16:
+		return float64(v), nil
+	case uint32:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case float32:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		if number, err := strconv.ParseFloat(v, 64); err == nil {
+			return number, nil
+		}
+	}
+	return 0, fmt.Errorf("value '%v' is not a number", value)
+}
+
+// matchesFormat checks value against well known formats of Swagger.
+// Unknown formats match any value
+func matchesFormat(format, value string) bool {
+	var err error
+	switch format {
+	case "int32":
+		_, err = strconv.ParseInt(value, 10, 32)
+	case "int64":
+		_, err = strconv.ParseInt(value, 10, 64)
+	case "float":
+		_, err = strconv.ParseFloat(value, 32)
+	case "double":
+		_, err = strconv.ParseFloat(value, 64)
+	case "byte":
+		_, err = base64.StdEncoding.DecodeString(value)
+	case "date":
+		_, err = time.Parse("2006-01-02", value)
+	case "date-time":
+		_, err = time.Parse(time.RFC3339, value)
+	case "uuid":
+		return uuidPattern.MatchString(value)
+	}
+	return err == nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
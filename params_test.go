@@ -0,0 +1,188 @@
+package apitest
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/go-openapi/spec"
+	"github.com/jarcoal/httpmock"
+	"github.com/seesawlabs/raml"
+	"github.com/stretchr/testify/assert"
+)
+
+func float64Ptr(v float64) *float64 { return &v }
+func int64Ptr(v int64) *int64       { return &v }
+
+type ListOrdersTest struct {
+	limit  int
+	status string
+}
+
+func (t *ListOrdersTest) Method() string      { return "GET" }
+func (t *ListOrdersTest) Description() string { return "Test for listing orders" }
+func (t *ListOrdersTest) Path() string        { return "/orders" }
+func (t *ListOrdersTest) TestCases() []ApiTestCase {
+	return []ApiTestCase{
+		{
+			Description: "Orders of given status",
+			QueryParams: ParamMap{
+				"limit": Param{
+					Value:   t.limit,
+					Minimum: float64Ptr(1),
+					Maximum: float64Ptr(100),
+					Format:  "int32",
+				},
+				"status": Param{
+					Value:   t.status,
+					Example: "shipped",
+					Enum:    []interface{}{"new", "shipped"},
+				},
+			},
+			ExpectedHttpCode: 200,
+			ExpectedData:     []string{},
+		},
+		{
+			Description:      "Too many orders requested",
+			QueryParams:      ParamMap{"limit": Param{Value: 1000, Maximum: float64Ptr(100)}},
+			ExpectedHttpCode: 400,
+		},
+	}
+}
+
+func TestValidateParam(t *testing.T) {
+	for _, tc := range []struct {
+		param Param
+		err   string
+	}{
+		{Param{Value: 5, Minimum: float64Ptr(1), Maximum: float64Ptr(10)}, ""},
+		{Param{Value: 0, Minimum: float64Ptr(1)}, "value 0 is less than minimum 1"},
+		{Param{Value: "11", Maximum: float64Ptr(10)}, "value 11 is greater than maximum 10"},
+		{Param{Value: "ten", Maximum: float64Ptr(10)}, "value 'ten' is not a number"},
+		{Param{Value: "new", Enum: []interface{}{"new", "shipped"}}, ""},
+		{Param{Value: "lost", Enum: []interface{}{"new", "shipped"}}, "value 'lost' is not one of new, shipped"},
+		{Param{Value: 2, Enum: []interface{}{1, 2}}, ""},
+		{Param{Value: "go", MinLength: int64Ptr(3)}, "value 'go' is shorter than 3 characters"},
+		{Param{Value: "gopher", MaxLength: int64Ptr(3)}, "value 'gopher' is longer than 3 characters"},
+		{Param{Value: "ab-12", Pattern: `^[a-z]+-\d+$`}, ""},
+		{Param{Value: "ab12", Pattern: `^[a-z]+-\d+$`}, `value 'ab12' does not match pattern '^[a-z]+-\d+$'`},
+		{Param{Value: "2016-02-29", Format: "date"}, ""},
+		{Param{Value: "2016-02-30", Format: "date"}, "value '2016-02-30' is not of format 'date'"},
+		{Param{Value: "9e107d9d-372b-4d1d-8d6b-5c2e3c4f5a6b", Format: "uuid"}, ""},
+		{Param{Value: "whatever", Format: "hostname"}, ""},
+		{Param{Value: "a", CollectionFormat: "json"}, "unknown collection format 'json'"},
+	} {
+		err := validateParam(tc.param)
+		if tc.err == "" {
+			assert.NoError(t, err, "%v", tc.param.Value)
+		} else {
+			assert.EqualError(t, err, tc.err)
+		}
+	}
+}
+
+func TestParamConstraintsValidated(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", "http://testapi.my/orders",
+		httpmock.NewStringResponder(400, ""))
+
+	runner := NewRunner("http://testapi.my", RunnerConfig{})
+	report, err := runner.RunSuite(context.Background(), []IApiTest{&ListOrdersTest{limit: 500, status: "new"}})
+	assert.NoError(t, err)
+
+	cases := report.Tests[0].Cases
+	assert.Equal(t, []string{"case 'Orders of given status' is not sent: invalid query param 'limit': value 500 is greater than maximum 100"},
+		cases[0].Failures)
+	assert.True(t, cases[1].Passed, "negative cases are not validated")
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+}
+
+func TestParamConstraintsDocumented(t *testing.T) {
+	test := &ListOrdersTest{limit: 10, status: "new"}
+	out, err := NewSwaggerGeneratorJSON(spec.Swagger{}).Generate([]IApiTest{test})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	doc := spec.Swagger{}
+	assert.NoError(t, json.Unmarshal(out, &doc))
+	params := map[string]spec.Parameter{}
+	for _, param := range doc.Paths.Paths["/orders"].Get.Parameters {
+		params[param.Name] = param
+	}
+	assert.Equal(t, float64Ptr(1), params["limit"].Minimum)
+	assert.Equal(t, float64Ptr(100), params["limit"].Maximum)
+	assert.Equal(t, "int32", params["limit"].Format)
+	assert.Equal(t, []interface{}{"new", "shipped"}, params["status"].Enum)
+	assert.Equal(t, "shipped", params["status"].Extensions["x-example"])
+
+	param := generateRamlNamedParameter("status", test.TestCases()[0].QueryParams["status"])
+	assert.Equal(t, raml.NamedParameter{
+		Name:    "status",
+		Type:    "string",
+		Default: "new",
+		Example: "shipped",
+		Enum:    []interface{}{"new", "shipped"},
+	}, param)
+
+	out, err = NewMarkdownGenerator(DocInfo{}).Generate([]IApiTest{test})
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(string(out), "| status | false |  | `shipped` |"))
+}
+
+func TestCollectionParams(t *testing.T) {
+	testCase := ApiTestCase{
+		Headers: ParamMap{
+			"X-Ids":  Param{Value: []int{1, 2}, CollectionFormat: CollectionMulti},
+			"X-Tags": Param{Value: []string{"a", "b"}},
+		},
+		QueryParams: ParamMap{
+			"id":    Param{Value: []int{1, 2}, CollectionFormat: CollectionMulti},
+			"csv":   Param{Value: []string{"a", "b"}},
+			"ssv":   Param{Value: []string{"a", "b"}, CollectionFormat: CollectionSSV},
+			"pipes": Param{Value: []interface{}{"a", 3}, CollectionFormat: CollectionPipes},
+		},
+	}
+
+	u, err := testCase.Url("http://testapi.my/orders")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://testapi.my/orders?csv=a%2Cb&id=1&id=2&pipes=a%7C3&ssv=a+b", u)
+
+	req, _, err := NewRunner("http://testapi.my", RunnerConfig{}).newRequest(testCase, "GET", "/orders")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"1", "2"}, req.Header["X-Ids"])
+		assert.Equal(t, []string{"a,b"}, req.Header["X-Tags"])
+	}
+
+	assert.EqualError(t, validateParam(Param{Value: []int{5, 500}, Maximum: float64Ptr(100)}),
+		"item 1: value 500 is greater than maximum 100")
+
+	specParam, err := generateSwaggerSpecParam("id", Param{
+		Value:            []int{1, 2},
+		CollectionFormat: CollectionMulti,
+		Maximum:          float64Ptr(100),
+	}, "query")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "array", specParam.Type)
+		assert.Equal(t, "multi", specParam.CollectionFormat)
+		assert.Equal(t, "integer", specParam.Items.Type)
+		assert.Equal(t, float64Ptr(100), specParam.Items.Maximum)
+		assert.Nil(t, specParam.Maximum)
+	}
+
+	// Swagger allows multi for query params only, repeated headers are a csv list
+	specParam, err = generateSwaggerSpecParam("X-Ids", testCase.Headers["X-Ids"], "header")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "csv", specParam.CollectionFormat)
+	}
+
+	ramlParam := generateRamlNamedParameter("id", Param{Value: []int{1, 2}, CollectionFormat: CollectionMulti})
+	assert.Equal(t, "integer", ramlParam.Type)
+	assert.True(t, *ramlParam.Repeat)
+	ramlParam = generateRamlNamedParameter("tags", Param{Value: []string{"a", "b"}, Description: "Tags"})
+	assert.Equal(t, "string", ramlParam.Type)
+	assert.Equal(t, "a,b", ramlParam.Default)
+	assert.Equal(t, "Tags (comma separated list of string)", ramlParam.Description)
+}
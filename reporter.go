@@ -0,0 +1,110 @@
+package apitest
+
+import (
+	"fmt"
+	"time"
+)
+
+// IReporter receives notifications about progress of a test run.
+// Reporters are provided to the runner with RunnerConfig.Reporters
+type IReporter interface {
+	TestStarted(test IApiTest)
+	CaseStarted(test IApiTest, caseIndex int, testCase ApiTestCase)
+	CaseFinished(test IApiTest, result CaseResult)
+	TestFinished(test IApiTest)
+}
+
+// IFlushable defines interface for reporters that need to write their
+// report when the run is finished
+type IFlushable interface {
+	Flush() error
+}
+
+// CaseResult describes outcome of a single test case
+type CaseResult struct {
+	TestName         string
+	Description      string
+	Method           string
+	Url              string
+	ExpectedHttpCode int
+	HttpCode         int
+	Passed           bool
+	Duration         time.Duration
+	// Durations contains round trip times of all runs of repeated case
+	Durations []time.Duration
+	// P50 and P95 are latency percentiles of repeated case
+	P50 time.Duration
+	P95 time.Duration
+
+	// Attempts is a number of times the case was run, more than 1 if
+	// the case was retried
+	Attempts int
+
+	// Skipped is set if the case was not run since it's skipped or pending
+	Skipped bool
+	// KnownFailure is set if the case failed as expected
+	KnownFailure bool
+	// Reason is a reason the case was skipped for or known to fail for
+	Reason string
+
+	// Failures contains messages of all failed assertions of the case
+	Failures []string
+}
+
+// TestResult describes outcome of all test cases of an IApiTest
+type TestResult struct {
+	Name        string
+	Description string
+	Cases       []CaseResult
+}
+
+// Passed tells whether all the cases of the test passed
+func (r TestResult) Passed() bool {
+	for _, c := range r.Cases {
+		if !c.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// resultCollector accumulates results of test run. Used as a base for
+// reporters that need to see all the results before writing a report
+type resultCollector struct {
+	results []TestResult
+}
+
+func (c *resultCollector) TestStarted(test IApiTest) {
+	c.results = append(c.results, TestResult{
+		Name:        extractTestName(test),
+		Description: test.Description(),
+	})
+}
+
+func (c *resultCollector) CaseStarted(test IApiTest, caseIndex int, testCase ApiTestCase) {}
+
+func (c *resultCollector) CaseFinished(test IApiTest, result CaseResult) {
+	if len(c.results) == 0 {
+		c.TestStarted(test)
+	}
+	last := &c.results[len(c.results)-1]
+	last.Cases = append(last.Cases, result)
+}
+
+func (c *resultCollector) TestFinished(test IApiTest) {}
+
+// flush returns collected results and resets the collector
+func (c *resultCollector) flush() []TestResult {
+	results := c.results
+	c.results = nil
+
+	return results
+}
+
+// caseName provides a name of test case that is used in reports
+func caseName(result CaseResult, caseIndex int) string {
+	if result.Description != "" {
+		return result.Description
+	}
+	return fmt.Sprintf("case %d", caseIndex)
+}
@@ -0,0 +1,99 @@
+package apitest
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+type jsonReporter struct {
+	resultCollector
+	writer io.Writer
+}
+
+// NewJSONReporter creates a reporter that writes results of the run
+// as JSON into given writer when the run is finished
+func NewJSONReporter(writer io.Writer) IReporter {
+	return &jsonReporter{writer: writer}
+}
+
+type jsonReport struct {
+	Passed bool             `json:"passed"`
+	Tests  []jsonTestReport `json:"tests"`
+}
+
+type jsonTestReport struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Passed      bool             `json:"passed"`
+	Cases       []jsonCaseReport `json:"cases"`
+}
+
+type jsonCaseReport struct {
+	Description      string   `json:"description"`
+	Method           string   `json:"method"`
+	Url              string   `json:"url"`
+	ExpectedHttpCode int      `json:"expected_status"`
+	HttpCode         int      `json:"status"`
+	Passed           bool     `json:"passed"`
+	DurationMs       float64  `json:"duration_ms"`
+	P50Ms            float64  `json:"p50_ms,omitempty"`
+	P95Ms            float64  `json:"p95_ms,omitempty"`
+	Attempts         int      `json:"attempts"`
+	Skipped          bool     `json:"skipped,omitempty"`
+	KnownFailure     bool     `json:"known_failure,omitempty"`
+	Reason           string   `json:"reason,omitempty"`
+	Failures         []string `json:"failures,omitempty"`
+}
+
+// Flush implements IFlushable
+func (r *jsonReporter) Flush() error {
+	report := jsonReport{Passed: true, Tests: newJsonTestReports(r.flush())}
+	for _, testReport := range report.Tests {
+		report.Passed = report.Passed && testReport.Passed
+	}
+
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = r.writer.Write(append(content, '\n'))
+	return err
+}
+
+// newJsonTestReports converts results of tests into their JSON representation
+func newJsonTestReports(results []TestResult) []jsonTestReport {
+	reports := []jsonTestReport{}
+	for _, test := range results {
+		testReport := jsonTestReport{
+			Name:        test.Name,
+			Description: test.Description,
+			Passed:      test.Passed(),
+			Cases:       []jsonCaseReport{},
+		}
+
+		for _, result := range test.Cases {
+			testReport.Cases = append(testReport.Cases, jsonCaseReport{
+				Description:      result.Description,
+				Method:           result.Method,
+				Url:              result.Url,
+				ExpectedHttpCode: result.ExpectedHttpCode,
+				HttpCode:         result.HttpCode,
+				Passed:           result.Passed,
+				DurationMs:       float64(result.Duration) / float64(time.Millisecond),
+				P50Ms:            float64(result.P50) / float64(time.Millisecond),
+				P95Ms:            float64(result.P95) / float64(time.Millisecond),
+				Attempts:         result.Attempts,
+				Skipped:          result.Skipped,
+				KnownFailure:     result.KnownFailure,
+				Reason:           result.Reason,
+				Failures:         result.Failures,
+			})
+		}
+
+		reports = append(reports, testReport)
+	}
+
+	return reports
+}
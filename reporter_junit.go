@@ -0,0 +1,118 @@
+package apitest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type junitReporter struct {
+	resultCollector
+	writer io.Writer
+}
+
+// NewJUnitReporter creates a reporter that writes results of the run
+// as JUnit XML into given writer when the run is finished
+func NewJUnitReporter(writer io.Writer) IReporter {
+	return &junitReporter{writer: writer}
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message  string `xml:"message,attr"`
+	Contents string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// Flush implements IFlushable
+func (r *junitReporter) Flush() error {
+	report := junitTestSuites{}
+
+	var total time.Duration
+	for _, test := range r.flush() {
+		suite := junitTestSuite{Name: test.Name}
+
+		var suiteTime time.Duration
+		for caseIndex, result := range test.Cases {
+			testCase := junitTestCase{
+				Name:      caseName(result, caseIndex),
+				ClassName: test.Name,
+				Time:      junitDuration(result.Duration),
+				SystemOut: fmt.Sprintf("%s %s -> %d (expected %d)",
+					result.Method, result.Url, result.HttpCode, result.ExpectedHttpCode),
+			}
+			if result.Skipped {
+				testCase.Skipped = &junitSkipped{Message: result.Reason}
+				testCase.SystemOut = ""
+				suite.Skipped++
+			}
+			if !result.Passed {
+				testCase.Failure = &junitFailure{
+					Message:  fmt.Sprintf("%d assertion(s) failed", len(result.Failures)),
+					Contents: strings.Join(result.Failures, "\n"),
+				}
+				suite.Failures++
+			}
+
+			suite.Cases = append(suite.Cases, testCase)
+			suite.Tests++
+			suiteTime += result.Duration
+		}
+		suite.Time = junitDuration(suiteTime)
+
+		report.Suites = append(report.Suites, suite)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+		total += suiteTime
+	}
+	report.Time = junitDuration(total)
+
+	content, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.WriteString(r.writer, xml.Header); err != nil {
+		return err
+	}
+	_, err = r.writer.Write(append(content, '\n'))
+
+	return err
+}
+
+// junitDuration formats duration in seconds, as JUnit expects
+func junitDuration(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,125 @@
+package apitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReporters(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	setupMock()
+
+	junitOutput := &bytes.Buffer{}
+	jsonOutput := &bytes.Buffer{}
+
+	runner := NewRunner("http://testapi.my", RunnerConfig{
+		Reporters: []IReporter{
+			NewJUnitReporter(junitOutput),
+			NewJSONReporter(jsonOutput),
+		},
+	})
+	runner.Run(t, &GetUserTest{}, &DeleteUserTest{})
+
+	junit := junitTestSuites{}
+	if assert.NoError(t, xml.Unmarshal(junitOutput.Bytes(), &junit)) {
+		assert.Equal(t, 6, junit.Tests)
+		assert.Equal(t, 0, junit.Failures)
+		if assert.Len(t, junit.Suites, 2) {
+			assert.Equal(t, "*apitest.GetUserTest", junit.Suites[0].Name)
+			assert.Equal(t, "404 error in case user not found", junit.Suites[0].Cases[1].Name)
+			assert.Equal(t, "GET http://testapi.my/user/someveryunknown -> 404 (expected 404)",
+				junit.Suites[0].Cases[1].SystemOut)
+		}
+	}
+
+	report := jsonReport{}
+	if assert.NoError(t, json.Unmarshal(jsonOutput.Bytes(), &report)) {
+		assert.True(t, report.Passed)
+		if assert.Len(t, report.Tests, 2) && assert.Len(t, report.Tests[1].Cases, 3) {
+			c := report.Tests[1].Cases[0]
+			assert.Equal(t, "User deleted successfully", c.Description)
+			assert.Equal(t, "DELETE", c.Method)
+			assert.Equal(t, "http://testapi.my/user/octocat", c.Url)
+			assert.Equal(t, 204, c.HttpCode)
+			assert.True(t, c.Passed)
+		}
+	}
+}
+
+// eventReporter records notifications of the runner in order
+type eventReporter struct {
+	events []string
+}
+
+func (r *eventReporter) TestStarted(test IApiTest) {
+	r.events = append(r.events, "test started: "+extractTestName(test))
+}
+
+func (r *eventReporter) CaseStarted(test IApiTest, caseIndex int, testCase ApiTestCase) {
+	r.events = append(r.events, fmt.Sprintf("case %d started: %s", caseIndex, testCase.Description))
+}
+
+func (r *eventReporter) CaseFinished(test IApiTest, result CaseResult) {
+	r.events = append(r.events, fmt.Sprintf("case finished: %s, passed %t", result.Description, result.Passed))
+}
+
+func (r *eventReporter) TestFinished(test IApiTest) {
+	r.events = append(r.events, "test finished: "+extractTestName(test))
+}
+
+func TestReportersSetUpFailure(t *testing.T) {
+	junitOutput := &bytes.Buffer{}
+	jsonOutput := &bytes.Buffer{}
+	events := &eventReporter{}
+
+	runner := NewRunner("http://testapi.my", RunnerConfig{
+		Reporters: []IReporter{
+			events,
+			NewJUnitReporter(junitOutput),
+			NewJSONReporter(jsonOutput),
+		},
+	})
+	mockT := new(testing.T)
+	runner.Run(mockT, &BrokenSetUpHelloTest{})
+	assert.True(t, mockT.Failed())
+
+	assert.Equal(t, []string{
+		"test started: *apitest.BrokenSetUpHelloTest",
+		"case 0 started: test setup",
+		"case finished: test setup, passed false",
+		"test finished: *apitest.BrokenSetUpHelloTest",
+	}, events.events)
+
+	failure := "error setting up test '*apitest.BrokenSetUpHelloTest'(Test for HelloWorld API handler): no fixtures"
+
+	junit := junitTestSuites{}
+	if assert.NoError(t, xml.Unmarshal(junitOutput.Bytes(), &junit)) {
+		assert.Equal(t, 1, junit.Tests)
+		assert.Equal(t, 1, junit.Failures)
+		if assert.Len(t, junit.Suites, 1) && assert.Len(t, junit.Suites[0].Cases, 1) {
+			c := junit.Suites[0].Cases[0]
+			assert.Equal(t, "test setup", c.Name)
+			if assert.NotNil(t, c.Failure) {
+				assert.Contains(t, c.Failure.Contents, failure)
+			}
+		}
+	}
+
+	report := jsonReport{}
+	if assert.NoError(t, json.Unmarshal(jsonOutput.Bytes(), &report)) {
+		assert.False(t, report.Passed)
+		if assert.Len(t, report.Tests, 1) && assert.Len(t, report.Tests[0].Cases, 1) {
+			c := report.Tests[0].Cases[0]
+			assert.Equal(t, "test setup", c.Description)
+			assert.False(t, c.Passed)
+			assert.Equal(t, []string{failure}, c.Failures)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package apitest
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// IMissingParamCode defines interface for tests that want the runner to
+// expect particular HTTP code when a required parameter is missing.
+// By default any 4xx code is accepted.
+//
+// Used when RunnerConfig.CheckRequiredParams is enabled
+type IMissingParamCode interface {
+	MissingParamHttpCode() int
+}
+
+// Kinds of parameters that can be dropped from a test case
+const (
+	paramKindHeader = "header"
+	paramKindQuery  = "query"
+	paramKindPath   = "path"
+)
+
+// missingParam tells which parameter is dropped from a derived test case
+type missingParam struct {
+	kind string
+	name string
+	// templateVar is set for query params expanded by the path template
+	// from PathParams, e.g. '{?q}'
+	templateVar bool
+}
+
+func (p missingParam) String() string {
+	return fmt.Sprintf("%s param '%s'", p.kind, p.name)
+}
+
+// missingParamCases derives negative cases from successful cases of the test.
+// Each derived case lacks exactly one of required header, query or path
+// parameters, so the API is expected to reject it with 4xx. Path segments
+// are expanded empty rather than dropped, so the request still targets the
+// same route and is expected to be rejected with 404 or 400
+func missingParamCases(test IApiTest) []ApiTestCase {
+	tmpl := templateOf(test)
+	expectedCode := 0
+	if coded, ok := test.(IMissingParamCode); ok {
+		expectedCode = coded.MissingParamHttpCode()
+	}
+
+	var cases []ApiTestCase
+	for _, testCase := range test.TestCases() {
+		if testCase.ExpectedHttpCode < 200 || testCase.ExpectedHttpCode >= 300 {
+			continue
+		}
+
+		for _, group := range paramGroups(testCase) {
+			for _, name := range sortedParamNames(group.params) {
+				if !group.params[name].Required {
+					continue
+				}
+
+				missing := missingParam{kind: group.kind, name: name}
+				if group.kind == paramKindPath && tmpl.isQueryVar(name) {
+					missing = missingParam{kind: paramKindQuery, name: name, templateVar: true}
+				}
+				derived := testCase
+				derived.Description = fmt.Sprintf("%s, missing required %s", testCase.Description, missing)
+				derived.ExpectedHttpCode = expectedCode
+				derived.ExpectedHeaders = nil
+				derived.ExpectedData = nil
+				derived.AssertResponse = nil
+				derived.AssertBody = nil
+				// a known bug of the case doesn't mean the param is not checked
+				derived.ExpectedFailure = ""
+				derived.missing = &missing
+
+				cases = append(cases, derived.withoutMissingParam())
+			}
+		}
+	}
+
+	return cases
+}
+
+// withoutMissingParam returns a copy of the test case that lacks the parameter
+// the case is derived for. Case hooks may put the parameter back, so it's
+// dropped again right before the request is sent
+func (testCase ApiTestCase) withoutMissingParam() ApiTestCase {
+	if testCase.missing == nil {
+		return testCase
+	}
+
+	switch testCase.missing.kind {
+	case paramKindHeader:
+		testCase.Headers = withoutParam(testCase.Headers, testCase.missing.name)
+	case paramKindQuery:
+		if testCase.missing.templateVar {
+			testCase.PathParams = withoutParam(testCase.PathParams, testCase.missing.name)
+		} else {
+			testCase.QueryParams = withoutParam(testCase.QueryParams, testCase.missing.name)
+		}
+	case paramKindPath:
+		// the segment is kept empty, dropping it would change the route
+		testCase.PathParams = withoutParam(testCase.PathParams, testCase.missing.name)
+		testCase.PathParams[testCase.missing.name] = Param{Value: ""}
+	}
+
+	return testCase
+}
+
+// withoutParam copies given params leaving out the one with given name
+func withoutParam(params ParamMap, name string) ParamMap {
+	result := ParamMap{}
+	for paramName, param := range params {
+		if paramName != name {
+			result[paramName] = param
+		}
+	}
+	return result
+}
+
+// assertMissingParamRejected checks that API rejected a request with missing
+// required parameter
+func assertMissingParamRejected(t *caseT, testCase ApiTestCase, resp *http.Response, responseBody []byte) bool {
+	rejected := resp.StatusCode >= 400 && resp.StatusCode < 500
+	if testCase.ExpectedHttpCode != 0 {
+		rejected = resp.StatusCode == testCase.ExpectedHttpCode
+	}
+	if rejected {
+		return true
+	}
+
+	expected := "4xx"
+	if testCase.ExpectedHttpCode != 0 {
+		expected = fmt.Sprintf("%d", testCase.ExpectedHttpCode)
+	}
+	t.Errorf("endpoint accepts request missing required %s: expected %s, got %d",
+		testCase.missing, expected, resp.StatusCode)
+	t.Logf("body received: %s", string(responseBody))
+
+	return false
+}
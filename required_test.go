@@ -0,0 +1,155 @@
+package apitest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type SearchTest struct {
+	missingParamCode int
+}
+
+func (t *SearchTest) Method() string      { return "GET" }
+func (t *SearchTest) Description() string { return "Test for searching" }
+func (t *SearchTest) Path() string        { return "/search" }
+func (t *SearchTest) TestCases() []ApiTestCase {
+	return []ApiTestCase{
+		{
+			Description: "Successful search",
+			Headers: ParamMap{
+				"X-Token": Param{Value: "token", Required: true},
+			},
+			QueryParams: ParamMap{
+				"q":     Param{Value: "gopher", Required: true},
+				"limit": Param{Value: 10, Required: true},
+				"page":  Param{Value: 1},
+			},
+			ExpectedHttpCode: 200,
+		},
+		{
+			Description:      "Unauthorized search",
+			Headers:          ParamMap{"X-Token": Param{Value: "invalid", Required: true}},
+			QueryParams:      ParamMap{"q": Param{Value: "gopher", Required: true}},
+			ExpectedHttpCode: 401,
+		},
+	}
+}
+
+func (t *SearchTest) MissingParamHttpCode() int { return t.missingParamCode }
+
+func TestMissingParamCases(t *testing.T) {
+	cases := missingParamCases(&SearchTest{})
+	if assert.Len(t, cases, 3) {
+		assert.Equal(t, "Successful search, missing required header param 'X-Token'", cases[0].Description)
+		assert.NotContains(t, cases[0].Headers, "X-Token")
+		assert.Equal(t, "Successful search, missing required query param 'limit'", cases[1].Description)
+		assert.NotContains(t, cases[1].QueryParams, "limit")
+		assert.Contains(t, cases[1].QueryParams, "q")
+		assert.Equal(t, "Successful search, missing required query param 'q'", cases[2].Description)
+		assert.Equal(t, 0, cases[2].ExpectedHttpCode)
+	}
+
+	cases = missingParamCases(&SearchTest{missingParamCode: 422})
+	assert.Equal(t, 422, cases[0].ExpectedHttpCode)
+}
+
+func TestMissingTemplateParamCases(t *testing.T) {
+	cases := missingParamCases(&SearchRepoTest{pathParams: ParamMap{
+		"owner": Param{Value: "octocat", Required: true},
+		"repo":  Param{Value: "hello", Required: true},
+		"q":     Param{Value: "gopher", Required: true},
+	}})
+
+	// path segments are expanded empty, the template query variable is dropped
+	if assert.Len(t, cases, 3) {
+		assert.Equal(t, "Successful search, missing required path param 'owner'", cases[0].Description)
+		assert.Equal(t, Param{Value: ""}, cases[0].PathParams["owner"])
+		assert.NoError(t, validatePathParams("/repos/{owner}{/repo}/search{?q}", cases[0]))
+		url, err := cases[0].Url("http://testapi.my/repos/{owner}{/repo}/search{?q}")
+		assert.NoError(t, err)
+		assert.Equal(t, "http://testapi.my/repos//hello/search?page=2&q=gopher", url)
+
+		assert.Equal(t, "Successful search, missing required query param 'q'", cases[1].Description)
+		assert.NotContains(t, cases[1].PathParams, "q")
+		assert.Contains(t, cases[1].PathParams, "owner")
+		assert.NoError(t, validatePathParams("/repos/{owner}{/repo}/search{?q}", cases[1]))
+
+		assert.Equal(t, "Successful search, missing required path param 'repo'", cases[2].Description)
+		url, err = cases[2].Url("http://testapi.my/repos/{owner}{/repo}/search{?q}")
+		assert.NoError(t, err)
+		assert.Equal(t, "http://testapi.my/repos/octocat//search?page=2&q=gopher", url)
+	}
+}
+
+func TestCheckRequiredParams(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	// the endpoint forgets to validate 'limit'
+	httpmock.RegisterResponder("GET", "http://testapi.my/search",
+		func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("X-Token") != "token" {
+				return httpmock.NewStringResponse(401, ""), nil
+			}
+			if req.URL.Query().Get("q") == "" {
+				return httpmock.NewStringResponse(400, ""), nil
+			}
+			return httpmock.NewStringResponse(200, ""), nil
+		},
+	)
+
+	collector := &resultCollector{}
+	runner := NewRunner("http://testapi.my", RunnerConfig{
+		HttpClient: &http.Client{Transport: httpmock.DefaultTransport},
+		// default header must not sneak the dropped header into the request
+		DefaultHeaders:      map[string]string{"X-Token": "token"},
+		Reporters:           []IReporter{collector},
+		CheckRequiredParams: true,
+	})
+
+	mockT := new(testing.T)
+	runner.Run(mockT, &SearchTest{})
+	assert.True(t, mockT.Failed())
+
+	results := collector.flush()[0].Cases
+	if assert.Len(t, results, 5) {
+		assert.True(t, results[0].Passed)
+		assert.True(t, results[1].Passed)
+		assert.True(t, results[2].Passed, "missing header is rejected")
+		assert.False(t, results[3].Passed, "missing limit is accepted")
+		assert.Equal(t, []string{"endpoint accepts request missing required query param 'limit': expected 4xx, got 200"},
+			results[3].Failures)
+		assert.True(t, results[4].Passed, "missing q is rejected")
+	}
+}
+
+func TestCheckRequiredPathParams(t *testing.T) {
+	var paths []string
+	collector := &resultCollector{}
+	runner := NewRunner("http://testapi.my", RunnerConfig{
+		HttpClient: IHttpClientFunc(func(req *http.Request) (*http.Response, error) {
+			paths = append(paths, req.URL.Path)
+			if req.URL.Path != "/repos/octocat/hello/search" {
+				return httpmock.NewStringResponse(404, ""), nil
+			}
+			return httpmock.NewStringResponse(200, ""), nil
+		}),
+		Reporters:           []IReporter{collector},
+		CheckRequiredParams: true,
+	})
+
+	runner.Run(t, &SearchRepoTest{pathParams: ParamMap{
+		"owner": Param{Value: "octocat", Required: true},
+		"repo":  Param{Value: "hello", Required: true},
+		"q":     Param{Value: "gopher"},
+	}})
+
+	assert.Contains(t, paths, "/repos//hello/search")
+	assert.Contains(t, paths, "/repos/octocat//search")
+	for _, result := range collector.flush()[0].Cases {
+		assert.True(t, result.Passed, result.Description)
+	}
+}
@@ -0,0 +1,147 @@
+package apitest
+
+import (
+	"context"
+	"time"
+)
+
+// RetryPolicy defines when and how often a failed test case is retried.
+//
+// If neither OnHttpCodes nor OnAssertionFailure is set, any failure
+// triggers retry
+type RetryPolicy struct {
+	// MaxAttempts is a total number of attempts, including the first one
+	MaxAttempts int
+	// Backoff is a delay before the second attempt, doubled for each next
+	// one. 100ms by default
+	Backoff time.Duration
+	// MaxBackoff limits the delay between attempts, if set
+	MaxBackoff time.Duration
+
+	// OnHttpCodes lists response codes that trigger retry, e.g. 404
+	// for a resource that is not created yet
+	OnHttpCodes []int
+	// OnAssertionFailure makes any failed assertion trigger retry. Requests
+	// that failed with no response are not retried unless no triggers are set
+	OnAssertionFailure bool
+}
+
+// WaitPolicy defines how long the runner polls an endpoint
+type WaitPolicy struct {
+	// Timeout is a time after which the case fails if its assertions
+	// still don't pass
+	Timeout time.Duration
+	// Interval is a delay between attempts, 200ms by default
+	Interval time.Duration
+}
+
+const (
+	defaultRetryBackoff = 100 * time.Millisecond
+	defaultWaitInterval = 200 * time.Millisecond
+)
+
+// executeCaseWithRetries runs the test case until it passes or its retry
+// or wait policy gives up. Output of failed attempts is logged, only the
+// last attempt may fail the test
+func (r *httpRunner) executeCaseWithRetries(ctx context.Context, t *caseT, test IApiTest, testCase ApiTestCase, result *CaseResult) {
+	result.Attempts = 1
+	if testCase.Retry == nil && testCase.WaitUntil == nil {
+		r.executeCase(ctx, t, test, testCase, result)
+		return
+	}
+
+	var deadline time.Time
+	if testCase.WaitUntil != nil {
+		deadline = time.Now().Add(testCase.WaitUntil.Timeout)
+	}
+
+	for attempt := 1; ; attempt++ {
+		result.Attempts = attempt
+		result.HttpCode = 0
+
+		attemptT := &caseT{sink: t.sink, buffered: true}
+		r.executeCase(ctx, attemptT, test, testCase, result)
+		if len(attemptT.failures) == 0 {
+			attemptT.flushTo(t)
+			return
+		}
+
+		delay, retry := nextAttemptDelay(testCase, attempt, result.HttpCode, deadline)
+		if !retry {
+			attemptT.flushTo(t)
+			if testCase.WaitUntil != nil {
+				t.Errorf("case '%s' did not pass within %s, %d attempts made",
+					testCase.Description, testCase.WaitUntil.Timeout, attempt)
+			}
+			return
+		}
+
+		t.Logf("attempt %d of case '%s' failed (HTTP code %d): %s; retrying in %s",
+			attempt, testCase.Description, result.HttpCode, attemptT.failures[0], delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			attemptT.flushTo(t)
+			t.Errorf("case '%s' was cancelled while waiting for next attempt: %s", testCase.Description, ctx.Err())
+			return
+		}
+	}
+}
+
+// nextAttemptDelay tells whether the case should be run again after
+// given failed attempt, and how long to wait before that
+func nextAttemptDelay(testCase ApiTestCase, attempt, httpCode int, deadline time.Time) (time.Duration, bool) {
+	if wait := testCase.WaitUntil; wait != nil {
+		interval := wait.Interval
+		if interval == 0 {
+			interval = defaultWaitInterval
+		}
+
+		left := deadline.Sub(time.Now())
+		if left <= 0 {
+			return 0, false
+		}
+		if interval > left {
+			interval = left
+		}
+		return interval, true
+	}
+
+	policy := testCase.Retry
+	if attempt >= policy.MaxAttempts || !policy.triggeredBy(httpCode) {
+		return 0, false
+	}
+
+	delay := policy.Backoff
+	if delay == 0 {
+		delay = defaultRetryBackoff
+	}
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if policy.MaxBackoff > 0 && delay >= policy.MaxBackoff {
+			break
+		}
+	}
+	if policy.MaxBackoff > 0 && delay > policy.MaxBackoff {
+		delay = policy.MaxBackoff
+	}
+
+	return delay, true
+}
+
+// triggeredBy tells whether failed attempt with given response code must be retried
+func (p *RetryPolicy) triggeredBy(httpCode int) bool {
+	if len(p.OnHttpCodes) == 0 && !p.OnAssertionFailure {
+		return true
+	}
+	if p.OnAssertionFailure && httpCode != 0 {
+		// response was received, so it's assertions that failed
+		return true
+	}
+	for _, code := range p.OnHttpCodes {
+		if code == httpCode {
+			return true
+		}
+	}
+	return false
+}
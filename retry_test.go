@@ -0,0 +1,191 @@
+package apitest
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type EventualUserTest struct {
+	retry *RetryPolicy
+	wait  *WaitPolicy
+}
+
+func (t *EventualUserTest) Method() string      { return "GET" }
+func (t *EventualUserTest) Description() string { return "Test for user created by async worker" }
+func (t *EventualUserTest) Path() string        { return "/eventual/user" }
+func (t *EventualUserTest) TestCases() []ApiTestCase {
+	return []ApiTestCase{
+		{
+			Description:      "User is eventually created",
+			ExpectedHttpCode: 200,
+			ExpectedData:     "octocat",
+			Retry:            t.retry,
+			WaitUntil:        t.wait,
+		},
+	}
+}
+
+// registerEventualUser makes the user appear after given number of requests
+func registerEventualUser(notFoundTimes int) *int {
+	requests := 0
+	httpmock.RegisterResponder("GET", "http://testapi.my/eventual/user",
+		func(req *http.Request) (*http.Response, error) {
+			requests++
+			if requests <= notFoundTimes {
+				return httpmock.NewStringResponse(404, "not found"), nil
+			}
+			return httpmock.NewStringResponse(200, "octocat"), nil
+		},
+	)
+	return &requests
+}
+
+func TestRetry(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	requests := registerEventualUser(2)
+	collector := &resultCollector{}
+	runner := NewRunner("http://testapi.my", RunnerConfig{Reporters: []IReporter{collector}})
+	runner.Run(t, &EventualUserTest{retry: &RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     time.Millisecond,
+		OnHttpCodes: []int{404},
+	}})
+
+	assert.Equal(t, 3, *requests)
+	result := collector.flush()[0].Cases[0]
+	assert.True(t, result.Passed)
+	assert.Equal(t, 3, result.Attempts)
+	assert.Empty(t, result.Failures)
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	requests := registerEventualUser(5)
+	collector := &resultCollector{}
+	runner := NewRunner("http://testapi.my", RunnerConfig{Reporters: []IReporter{collector}})
+	runner.Run(new(testing.T), &EventualUserTest{retry: &RetryPolicy{
+		MaxAttempts: 2,
+		Backoff:     time.Millisecond,
+		OnHttpCodes: []int{404},
+	}})
+
+	assert.Equal(t, 2, *requests)
+	result := collector.flush()[0].Cases[0]
+	assert.False(t, result.Passed)
+	assert.Equal(t, 2, result.Attempts)
+	assert.Equal(t, 404, result.HttpCode)
+	assert.Len(t, result.Failures, 1, "only the last attempt is reported")
+}
+
+func TestRetryCustomAssertion(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	// the user is created first, its name is set by the next request
+	requests := 0
+	httpmock.RegisterResponder("GET", "http://testapi.my/eventual/user",
+		func(req *http.Request) (*http.Response, error) {
+			requests++
+			if requests == 1 {
+				return httpmock.NewStringResponse(200, "unnamed"), nil
+			}
+			return httpmock.NewStringResponse(200, "octocat"), nil
+		},
+	)
+
+	assertions := 0
+	test := &customAssertedUserTest{assertBody: func(t IAssertionSink, expected interface{}, responseBody []byte) bool {
+		assertions++
+		return assert.Equal(t, expected, string(responseBody))
+	}}
+	collector := &resultCollector{}
+	runner := NewRunner("http://testapi.my", RunnerConfig{Reporters: []IReporter{collector}})
+
+	// the failed first attempt must not fail the test
+	runner.Run(t, test)
+	assert.Equal(t, 2, assertions)
+	result := collector.flush()[0].Cases[0]
+	assert.True(t, result.Passed)
+	assert.Equal(t, 2, result.Attempts)
+
+	// AssertResponse reports failures right to the test, they can't be retried
+	requests = 0
+	test = &customAssertedUserTest{assertResponse: func(t *testing.T, expected interface{}, responseBody []byte) bool {
+		return assert.Equal(t, expected, string(responseBody))
+	}}
+	mockT := new(testing.T)
+	runner.Run(mockT, test)
+	assert.True(t, mockT.Failed())
+	assert.Equal(t, 0, requests)
+	assert.Equal(t, []string{"custom AssertResponse of case 'User is eventually named' can't be used with Retry, WaitUntil or ExpectedFailure, use AssertBody instead"},
+		collector.flush()[0].Cases[0].Failures)
+}
+
+type customAssertedUserTest struct {
+	EventualUserTest
+	assertResponse AssertResponseFunc
+	assertBody     AssertBodyFunc
+}
+
+func (t *customAssertedUserTest) TestCases() []ApiTestCase {
+	return []ApiTestCase{
+		{
+			Description:      "User is eventually named",
+			ExpectedHttpCode: 200,
+			ExpectedData:     "octocat",
+			AssertResponse:   t.assertResponse,
+			AssertBody:       t.assertBody,
+			Retry:            &RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond, OnAssertionFailure: true},
+		},
+	}
+}
+
+func TestWaitUntil(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	requests := registerEventualUser(3)
+	runner := NewRunner("http://testapi.my", RunnerConfig{})
+	runner.Run(t, &EventualUserTest{wait: &WaitPolicy{Timeout: time.Second, Interval: time.Millisecond}})
+	assert.Equal(t, 4, *requests)
+
+	httpmock.Reset()
+	registerEventualUser(1000)
+	collector := &resultCollector{}
+	runner = NewRunner("http://testapi.my", RunnerConfig{Reporters: []IReporter{collector}})
+	runner.Run(new(testing.T), &EventualUserTest{wait: &WaitPolicy{Timeout: 20 * time.Millisecond, Interval: 5 * time.Millisecond}})
+
+	result := collector.flush()[0].Cases[0]
+	assert.False(t, result.Passed)
+	assert.True(t, result.Attempts > 1)
+	assert.Contains(t, result.Failures[len(result.Failures)-1], "did not pass within 20ms")
+}
+
+func TestNextAttemptDelay(t *testing.T) {
+	testCase := ApiTestCase{Retry: &RetryPolicy{MaxAttempts: 5, Backoff: 10 * time.Millisecond, MaxBackoff: 30 * time.Millisecond}}
+
+	delay, retry := nextAttemptDelay(testCase, 1, 500, time.Time{})
+	assert.True(t, retry)
+	assert.Equal(t, 10*time.Millisecond, delay)
+
+	delay, _ = nextAttemptDelay(testCase, 2, 500, time.Time{})
+	assert.Equal(t, 20*time.Millisecond, delay)
+
+	delay, _ = nextAttemptDelay(testCase, 4, 500, time.Time{})
+	assert.Equal(t, 30*time.Millisecond, delay)
+
+	_, retry = nextAttemptDelay(testCase, 5, 500, time.Time{})
+	assert.False(t, retry)
+
+	testCase.Retry.OnAssertionFailure = true
+	_, retry = nextAttemptDelay(testCase, 1, 0, time.Time{})
+	assert.False(t, retry, "request failed with no response")
+}
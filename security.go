@@ -0,0 +1,229 @@
+package apitest
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/go-openapi/spec"
+	"github.com/seesawlabs/raml"
+)
+
+// ISecured defines interface for tests of endpoints that require authentication.
+// Doc generators use it to document security requirements of an endpoint.
+//
+// Each requirement refers to a security scheme by name, so the scheme must be
+// defined in the seed of generator, see SwaggerSecurityDefinitions and
+// RamlSecuritySchemes.
+type ISecured interface {
+	Security() []SecurityRequirement
+}
+
+// SecurityRequirement tells that an endpoint requires given security scheme
+type SecurityRequirement struct {
+	Scheme string
+	Scopes []string
+}
+
+// Possible types of SecurityScheme
+const (
+	SecurityBasic  = "basic"
+	SecurityApiKey = "apiKey"
+	SecurityOAuth2 = "oauth2"
+)
+
+// SecurityScheme describes a way API authenticates requests
+type SecurityScheme struct {
+	// Type is one of SecurityBasic, SecurityApiKey, SecurityOAuth2
+	Type        string
+	Description string
+
+	// Name and In define header or query parameter API key is passed in.
+	// Used by apiKey schemes only
+	Name string
+	In   string
+
+	// Flow is OAuth2 flow: application, password, implicit or accessCode.
+	// Used by oauth2 schemes only as well as following fields
+	Flow             string
+	AuthorizationUrl string
+	TokenUrl         string
+	Scopes           map[string]string
+}
+
+// ISecurityDescriber defines interface for auth providers that can describe
+// their security scheme. All built-in providers implement it
+type ISecurityDescriber interface {
+	SecurityScheme() SecurityScheme
+}
+
+// DescribeAuth builds security schemes from auth providers, so documentation
+// follows the configuration tests are run with. Keys of given map are names
+// of schemes. Fails if some provider can't describe itself
+func DescribeAuth(providers map[string]IAuthProvider) (map[string]SecurityScheme, error) {
+	schemes := map[string]SecurityScheme{}
+	for name, provider := range providers {
+		describer, ok := provider.(ISecurityDescriber)
+		if !ok {
+			return nil, fmt.Errorf("auth provider '%s' of type %T can't describe its security scheme", name, provider)
+		}
+		schemes[name] = describer.SecurityScheme()
+	}
+
+	return schemes, nil
+}
+
+// SwaggerSecurityDefinitions converts security schemes into swagger definitions.
+// The result is supposed to be put into a seed of swagger generator
+func SwaggerSecurityDefinitions(schemes map[string]SecurityScheme) spec.SecurityDefinitions {
+	defs := spec.SecurityDefinitions{}
+	for name, scheme := range schemes {
+		var def *spec.SecurityScheme
+		switch scheme.Type {
+		case SecurityApiKey:
+			def = spec.APIKeyAuth(scheme.Name, scheme.In)
+		case SecurityOAuth2:
+			switch scheme.Flow {
+			case "password":
+				def = spec.OAuth2Password(scheme.TokenUrl)
+			case "implicit":
+				def = spec.OAuth2Implicit(scheme.AuthorizationUrl)
+			case "accessCode":
+				def = spec.OAuth2AccessToken(scheme.AuthorizationUrl, scheme.TokenUrl)
+			default:
+				def = spec.OAuth2Application(scheme.TokenUrl)
+			}
+			for scope, description := range scheme.Scopes {
+				def.AddScope(scope, description)
+			}
+		default:
+			def = spec.BasicAuth()
+		}
+		def.Description = scheme.Description
+
+		defs[name] = def
+	}
+
+	return defs
+}
+
+// RamlSecuritySchemes converts security schemes into RAML ones.
+// The result is supposed to be put into a seed of RAML generator
+func RamlSecuritySchemes(schemes map[string]SecurityScheme) []map[string]raml.SecurityScheme {
+	names := make([]string, 0, len(schemes))
+	for name := range schemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := []map[string]raml.SecurityScheme{}
+	for _, name := range names {
+		scheme := schemes[name]
+		ramlScheme := raml.SecurityScheme{
+			Description: scheme.Description,
+		}
+
+		switch scheme.Type {
+		case SecurityApiKey:
+			ramlScheme.Type = "x-api-key"
+			param := raml.NamedParameter{Name: scheme.Name, Type: "string", Required: true}
+			if scheme.In == "query" {
+				ramlScheme.DescribedBy.QueryParameters = map[string]raml.NamedParameter{scheme.Name: param}
+			} else {
+				ramlScheme.DescribedBy.Headers = map[raml.HTTPHeader]raml.Header{
+					raml.HTTPHeader(scheme.Name): raml.Header(param),
+				}
+			}
+		case SecurityOAuth2:
+			ramlScheme.Type = "OAuth 2.0"
+			scopes := make([]string, 0, len(scheme.Scopes))
+			for scope := range scheme.Scopes {
+				scopes = append(scopes, scope)
+			}
+			sort.Strings(scopes)
+
+			ramlScheme.Settings = map[string]raml.Any{
+				"accessTokenUri":      scheme.TokenUrl,
+				"authorizationGrants": []string{ramlOAuth2Grant(scheme.Flow)},
+				"scopes":              scopes,
+			}
+			if scheme.AuthorizationUrl != "" {
+				ramlScheme.Settings["authorizationUri"] = scheme.AuthorizationUrl
+			}
+		default:
+			ramlScheme.Type = "Basic Authentication"
+		}
+
+		result = append(result, map[string]raml.SecurityScheme{name: ramlScheme})
+	}
+
+	return result
+}
+
+// ramlOAuth2Grant maps swagger OAuth2 flow to RAML 0.8 authorization grant
+func ramlOAuth2Grant(flow string) string {
+	switch flow {
+	case "password":
+		return "owner"
+	case "implicit":
+		return "token"
+	case "accessCode":
+		return "code"
+	}
+	return "credentials"
+}
+
+// securityRequirements returns security requirements of given test, if any
+func securityRequirements(test IApiTest) []SecurityRequirement {
+	if secured, ok := test.(ISecured); ok {
+		return secured.Security()
+	}
+	return nil
+}
+
+// swaggerSecurity builds security requirements of swagger operation for given test
+func swaggerSecurity(test IApiTest, defs spec.SecurityDefinitions) ([]map[string][]string, error) {
+	var security []map[string][]string
+	for _, requirement := range securityRequirements(test) {
+		if _, ok := defs[requirement.Scheme]; !ok {
+			return nil, unknownSchemeError(test, requirement.Scheme)
+		}
+
+		scopes := requirement.Scopes
+		if scopes == nil {
+			scopes = []string{}
+		}
+		security = append(security, map[string][]string{requirement.Scheme: scopes})
+	}
+
+	return security, nil
+}
+
+// ramlSecuredBy builds security requirements of RAML method for given test
+func ramlSecuredBy(test IApiTest, schemes []map[string]raml.SecurityScheme) ([]raml.DefinitionChoice, error) {
+	var securedBy []raml.DefinitionChoice
+	for _, requirement := range securityRequirements(test) {
+		found := false
+		for _, defs := range schemes {
+			if _, ok := defs[requirement.Scheme]; ok {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, unknownSchemeError(test, requirement.Scheme)
+		}
+
+		choice := raml.DefinitionChoice{Name: requirement.Scheme}
+		if len(requirement.Scopes) > 0 {
+			choice.Parameters = raml.DefinitionParameters{"scopes": requirement.Scopes}
+		}
+		securedBy = append(securedBy, choice)
+	}
+
+	return securedBy, nil
+}
+
+func unknownSchemeError(test IApiTest, scheme string) error {
+	return fmt.Errorf("test '%s' requires security scheme '%s' that is not defined in the seed",
+		extractTestName(test), scheme)
+}
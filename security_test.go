@@ -0,0 +1,98 @@
+package apitest
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/go-openapi/spec"
+	"github.com/seesawlabs/raml"
+	"github.com/stretchr/testify/assert"
+)
+
+type securedHelloTest struct {
+	HelloTest
+}
+
+func (t *securedHelloTest) Security() []SecurityRequirement {
+	return []SecurityRequirement{
+		{Scheme: "oauth", Scopes: []string{"greetings:read"}},
+		{Scheme: "key"},
+	}
+}
+
+func TestDescribeAuth(t *testing.T) {
+	schemes, err := DescribeAuth(map[string]IAuthProvider{
+		"basic": BasicAuth("user", "secret"),
+		"key":   ApiKeyQuery("api_key", "key"),
+		"oauth": OAuth2ClientCredentials(OAuth2Config{
+			TokenUrl: "http://auth.my/token",
+			Scopes:   []string{"greetings:read"},
+		}),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, SecurityScheme{Type: SecurityBasic}, schemes["basic"])
+	assert.Equal(t, SecurityScheme{Type: SecurityApiKey, Name: "api_key", In: "query"}, schemes["key"])
+	assert.Equal(t, SecurityScheme{
+		Type:     SecurityOAuth2,
+		Flow:     "application",
+		TokenUrl: "http://auth.my/token",
+		Scopes:   map[string]string{"greetings:read": ""},
+	}, schemes["oauth"])
+
+	_, err = DescribeAuth(map[string]IAuthProvider{"custom": NoAuth()})
+	assert.Error(t, err)
+}
+
+func TestSwaggerSecurity(t *testing.T) {
+	schemes := map[string]SecurityScheme{
+		"key": {Type: SecurityApiKey, Name: "X-Api-Key", In: "header"},
+		"oauth": {
+			Type:     SecurityOAuth2,
+			Flow:     "application",
+			TokenUrl: "http://auth.my/token",
+			Scopes:   map[string]string{"greetings:read": "Read greetings"},
+		},
+	}
+	seed := spec.Swagger{}
+	seed.SecurityDefinitions = SwaggerSecurityDefinitions(schemes)
+
+	out, err := NewSwaggerGeneratorJSON(seed).Generate([]IApiTest{&securedHelloTest{}})
+	assert.NoError(t, err)
+
+	doc := spec.Swagger{}
+	assert.NoError(t, json.Unmarshal(out, &doc))
+	assert.Equal(t, "apiKey", doc.SecurityDefinitions["key"].Type)
+	assert.Equal(t, "X-Api-Key", doc.SecurityDefinitions["key"].Name)
+	assert.Equal(t, "oauth2", doc.SecurityDefinitions["oauth"].Type)
+	assert.Equal(t, "Read greetings", doc.SecurityDefinitions["oauth"].Scopes["greetings:read"])
+	assert.Equal(t, []map[string][]string{
+		{"oauth": {"greetings:read"}},
+		{"key": {}},
+	}, doc.Paths.Paths["/hello"].Get.Security)
+
+	_, err = NewSwaggerGeneratorJSON(spec.Swagger{}).Generate([]IApiTest{&securedHelloTest{}})
+	assert.EqualError(t, err, "test '*apitest.securedHelloTest' requires security scheme 'oauth' that is not defined in the seed")
+}
+
+func TestRamlSecurity(t *testing.T) {
+	schemes := map[string]SecurityScheme{
+		"key":   {Type: SecurityApiKey, Name: "api_key", In: "query"},
+		"oauth": {Type: SecurityOAuth2, Flow: "password", TokenUrl: "http://auth.my/token"},
+	}
+	ramlSchemes := RamlSecuritySchemes(schemes)
+	assert.Len(t, ramlSchemes, 2)
+	assert.Equal(t, "x-api-key", ramlSchemes[0]["key"].Type)
+	assert.Contains(t, ramlSchemes[0]["key"].DescribedBy.QueryParameters, "api_key")
+	assert.Equal(t, "OAuth 2.0", ramlSchemes[1]["oauth"].Type)
+	assert.Equal(t, []string{"owner"}, ramlSchemes[1]["oauth"].Settings["authorizationGrants"])
+
+	securedBy, err := ramlSecuredBy(&securedHelloTest{}, ramlSchemes)
+	assert.NoError(t, err)
+	assert.Equal(t, []raml.DefinitionChoice{
+		{Name: "oauth", Parameters: raml.DefinitionParameters{"scopes": []string{"greetings:read"}}},
+		{Name: "key"},
+	}, securedBy)
+
+	_, err = NewRamlGenerator(raml.APIDefinition{}).Generate([]IApiTest{&securedHelloTest{}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,230 @@
+package apitest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// IAssertionSink receives failures and messages of a run. *testing.T
+// implements it, so does LogSink
+type IAssertionSink interface {
+	Errorf(format string, args ...interface{})
+	Logf(format string, args ...interface{})
+}
+
+// Report contains results of a run started with RunSuite
+type Report struct {
+	Tests    []TestResult
+	Duration time.Duration
+
+	// Errors contains failures that don't belong to any test case,
+	// e.g. errors of suite hooks or test teardown. Failed test setup is
+	// reported as a failed case of the test
+	Errors []string
+}
+
+// Passed tells whether all the tests passed with no errors
+func (r *Report) Passed() bool {
+	if len(r.Errors) > 0 {
+		return false
+	}
+	for _, test := range r.Tests {
+		if !test.Passed() {
+			return false
+		}
+	}
+	return true
+}
+
+// LogSink writes failures and messages of a run to given logger
+func LogSink(logger *log.Logger) IAssertionSink {
+	return &logSink{logger: logger}
+}
+
+type logSink struct {
+	logger *log.Logger
+}
+
+func (s *logSink) Errorf(format string, args ...interface{}) {
+	s.logger.Printf("FAIL: "+format, args...)
+}
+
+func (s *logSink) Logf(format string, args ...interface{}) {
+	s.logger.Printf(format, args...)
+}
+
+// discardSink drops everything it receives
+type discardSink struct{}
+
+func (discardSink) Errorf(format string, args ...interface{}) {}
+func (discardSink) Logf(format string, args ...interface{})   {}
+
+// RunSuite runs tests with no dependency on go test, so it can be used by
+// deploy hooks, monitors and other programs. Failures and messages of the run
+// are passed to RunnerConfig.Sink.
+//
+// Failed test cases don't make RunSuite return an error, check Report.Passed
+// instead. An error is returned if the run could not be completed: BeforeAll
+// hook failed or the context was cancelled.
+func (r *httpRunner) RunSuite(ctx context.Context, tests []IApiTest) (*Report, error) {
+	sink := r.Sink
+	if sink == nil {
+		sink = discardSink{}
+	}
+
+	return r.runSuite(ctx, sink, tests)
+}
+
+func (r *httpRunner) runSuite(ctx context.Context, sink IAssertionSink, tests []IApiTest) (report *Report, err error) {
+	report = &Report{}
+	fail := func(format string, args ...interface{}) {
+		message := fmt.Sprintf(format, args...)
+		report.Errors = append(report.Errors, message)
+		sink.Errorf("%s", message)
+	}
+
+	collector := &resultCollector{}
+	reporters := append([]IReporter{collector}, r.Reporters...)
+
+	started := time.Now()
+	defer func() {
+		report.Tests = collector.flush()
+		report.Duration = time.Since(started)
+
+		for _, reporter := range r.Reporters {
+			if flushable, ok := reporter.(IFlushable); ok {
+				if err := flushable.Flush(); err != nil {
+					fail("error writing a report: %s", err.Error())
+				}
+			}
+		}
+	}()
+
+	tests, err = r.selectTests(sink.Logf, tests)
+	if err != nil {
+		fail("%s", err.Error())
+		return report, err
+	}
+
+	if r.BeforeAll != nil {
+		if err := r.BeforeAll(r.caseContext(ctx)); err != nil {
+			fail("error running BeforeAll hook: %s", err.Error())
+			return report, fmt.Errorf("error running BeforeAll hook: %s", err.Error())
+		}
+	}
+	if r.AfterAll != nil {
+		defer func() {
+			if err := r.AfterAll(r.caseContext(ctx)); err != nil {
+				fail("error running AfterAll hook: %s", err.Error())
+			}
+		}()
+	}
+
+	for _, test := range tests {
+		testName := extractTestName(test)
+
+		// setup test
+		if setuppable, ok := test.(ISetuppable); ok {
+			sink.Logf("setting up test '%s'(%s)...", testName, test.Description())
+
+			if err := setuppable.SetUp(); err != nil {
+				message := fmt.Sprintf("error setting up test '%s'(%s): %s",
+					testName, test.Description(), err.Error())
+				sink.Errorf("%s", message)
+
+				// reported as a failed case, so reports don't miss the test
+				setupCase := ApiTestCase{Description: "test setup"}
+				result := CaseResult{
+					TestName:    testName,
+					Description: setupCase.Description,
+					Method:      test.Method(),
+					Failures:    []string{message},
+				}
+				for _, reporter := range reporters {
+					reporter.TestStarted(test)
+					reporter.CaseStarted(test, 0, setupCase)
+					reporter.CaseFinished(test, result)
+					reporter.TestFinished(test)
+				}
+
+				continue
+			}
+		}
+
+		// run test
+		for _, reporter := range reporters {
+			reporter.TestStarted(test)
+		}
+		testCases := test.TestCases()
+		if r.CheckRequiredParams {
+			testCases = append(testCases, missingParamCases(test)...)
+		}
+		for caseIndex, testCase := range testCases {
+			if ctx.Err() != nil {
+				fail("run cancelled before test '%s'(%s), case %d: %s",
+					testName, test.Description(), caseIndex, ctx.Err())
+				err = fmt.Errorf("run cancelled: %s", ctx.Err())
+				break
+			}
+
+			sink.Logf("running test '%s'(%s), case %d", testName, test.Description(), caseIndex)
+			for _, reporter := range reporters {
+				reporter.CaseStarted(test, caseIndex, testCase)
+			}
+
+			result := r.runTest(ctx, sink, test, testCase)
+			for _, reporter := range reporters {
+				reporter.CaseFinished(test, result)
+			}
+		}
+		for _, reporter := range reporters {
+			reporter.TestFinished(test)
+		}
+
+		// teardown test
+		if teardownable, ok := test.(ITeardownable); ok {
+			sink.Logf("tearing down test '%s'(%s)...", testName, test.Description())
+
+			if err := teardownable.TearDown(); err != nil {
+				fail("error cleaning up after a test '%s'(%s): %s",
+					testName, test.Description(), err.Error())
+			}
+		}
+
+		if err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// selectTests returns tests matching filters of the runner and command line
+// flags that are not excluded from the runner's environment. Tests left out
+// are logged
+func (r *httpRunner) selectTests(logf func(format string, args ...interface{}), tests []IApiTest) ([]IApiTest, error) {
+	filters := []TestFilter{r.Filter, flagFilter()}
+	for _, filter := range filters {
+		if err := filter.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid test filter: %s", err.Error())
+		}
+	}
+
+	var selected []IApiTest
+	for _, test := range tests {
+		testName := extractTestName(test)
+		if !filters[0].Match(test) || !filters[1].Match(test) {
+			logf("test '%s'(%s) is filtered out", testName, test.Description())
+			continue
+		}
+		if excludedFrom(test, r.Environment) {
+			logf("skipping test '%s'(%s) in environment '%s'", testName, test.Description(), r.Environment)
+			continue
+		}
+		selected = append(selected, test)
+	}
+
+	return selected, nil
+}
@@ -0,0 +1,106 @@
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type BodyAssertedHelloTest struct {
+	HelloTest
+	legacy bool
+}
+
+func (t *BodyAssertedHelloTest) TestCases() []ApiTestCase {
+	cases := t.HelloTest.TestCases()
+	if t.legacy {
+		cases[0].AssertResponse = AssertResponse
+	} else {
+		cases[0].AssertBody = func(t IAssertionSink, expected interface{}, responseBody []byte) bool {
+			return AssertResponseBody(t, "Hello Gophers!", responseBody)
+		}
+	}
+	return cases
+}
+
+func TestRunSuite(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	setupMock()
+
+	logs := &bytes.Buffer{}
+	runner := NewRunner("http://testapi.my", RunnerConfig{
+		Sink: LogSink(log.New(logs, "", 0)),
+	})
+
+	report, err := runner.RunSuite(context.Background(), []IApiTest{&HelloTest{}, &BodyAssertedHelloTest{}})
+	assert.NoError(t, err)
+	assert.False(t, report.Passed())
+	if assert.Len(t, report.Tests, 2) {
+		assert.True(t, report.Tests[0].Passed())
+		assert.False(t, report.Tests[1].Passed())
+		assert.Contains(t, report.Tests[1].Cases[0].Failures[0], "request and response are not equal")
+	}
+	assert.Empty(t, report.Errors)
+	assert.Contains(t, logs.String(), "running test '*apitest.HelloTest'")
+	assert.Contains(t, logs.String(), "FAIL: ")
+
+	// legacy assertion needs *testing.T
+	report, err = runner.RunSuite(context.Background(), []IApiTest{&BodyAssertedHelloTest{legacy: true}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"custom AssertResponse of case 'Successful greeting of the world' can only be run by go test, use AssertBody instead"},
+		report.Tests[0].Cases[0].Failures)
+}
+
+func TestRunSuiteErrors(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	setupMock()
+
+	runner := NewRunner("http://testapi.my", RunnerConfig{
+		BeforeAll: func(ctx *CaseContext) error { return errors.New("no database") },
+	})
+	report, err := runner.RunSuite(context.Background(), []IApiTest{&HelloTest{}})
+	assert.EqualError(t, err, "error running BeforeAll hook: no database")
+	assert.Equal(t, []string{"error running BeforeAll hook: no database"}, report.Errors)
+	assert.Empty(t, report.Tests)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	runner = NewRunner("http://testapi.my", RunnerConfig{HttpClient: &http.Client{}})
+	report, err = runner.RunSuite(ctx, []IApiTest{&HelloTest{}, &GetUserTest{}})
+	assert.EqualError(t, err, "run cancelled: context canceled")
+	assert.Len(t, report.Tests, 1, "the run stops at cancellation")
+	assert.False(t, report.Passed())
+}
+
+type BrokenSetUpHelloTest struct {
+	HelloTest
+}
+
+func (t *BrokenSetUpHelloTest) SetUp() error { return errors.New("no fixtures") }
+
+func TestRunSuiteSetUpFailure(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	setupMock()
+
+	junit := &bytes.Buffer{}
+	runner := NewRunner("http://testapi.my", RunnerConfig{Reporters: []IReporter{NewJUnitReporter(junit)}})
+	report, err := runner.RunSuite(context.Background(), []IApiTest{&BrokenSetUpHelloTest{}, &HelloTest{}})
+	assert.NoError(t, err)
+	assert.False(t, report.Passed())
+	if assert.Len(t, report.Tests, 2, "failed setup is reported") {
+		assert.Equal(t, "*apitest.BrokenSetUpHelloTest", report.Tests[0].Name)
+		assert.Equal(t, []string{"error setting up test '*apitest.BrokenSetUpHelloTest'(Test for HelloWorld API handler): no fixtures"},
+			report.Tests[0].Cases[0].Failures)
+		assert.True(t, report.Tests[1].Passed())
+	}
+	assert.Contains(t, junit.String(), `<testsuite name="*apitest.BrokenSetUpHelloTest" tests="1" failures="1"`)
+}
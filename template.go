@@ -0,0 +1,256 @@
+package apitest
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// templateVarName matches variable names of RFC 6570 URI templates
+var templateVarName = regexp.MustCompile(`^([A-Za-z0-9_]|%[0-9A-Fa-f]{2})+(\.([A-Za-z0-9_]|%[0-9A-Fa-f]{2})+)*$`)
+
+// pathTemplate is a parsed RFC 6570 template of IApiTest.Path
+type pathTemplate struct {
+	raw   string
+	parts []templatePart
+}
+
+// templatePart is either a literal or an expression of a template
+type templatePart struct {
+	literal string
+	expr    *templateExpr
+}
+
+// templateExpr is an expression like {var}, {/seg,id} or {?q,limit}
+type templateExpr struct {
+	operator string
+	vars     []string
+}
+
+// parsePathTemplate parses RFC 6570 template. Variable modifiers, like
+// prefix {var:3} and explode {var*}, are accepted and ignored
+func parsePathTemplate(path string) (*pathTemplate, error) {
+	tmpl := &pathTemplate{raw: path}
+	rest := path
+	for rest != "" {
+		open := strings.IndexAny(rest, "{}")
+		if open < 0 {
+			tmpl.parts = append(tmpl.parts, templatePart{literal: rest})
+			break
+		}
+		if rest[open] == '}' {
+			return nil, fmt.Errorf("path template '%s' has unmatched '}'", path)
+		}
+		if open > 0 {
+			tmpl.parts = append(tmpl.parts, templatePart{literal: rest[:open]})
+		}
+
+		end := strings.IndexAny(rest[open+1:], "{}")
+		if end < 0 || rest[open+1+end] != '}' {
+			return nil, fmt.Errorf("path template '%s' has unclosed '{'", path)
+		}
+		expr, err := parseTemplateExpr(rest[open+1 : open+1+end])
+		if err != nil {
+			return nil, fmt.Errorf("could not parse path template '%s': %s", path, err.Error())
+		}
+		tmpl.parts = append(tmpl.parts, templatePart{expr: expr})
+		rest = rest[open+end+2:]
+	}
+
+	return tmpl, nil
+}
+
+func parseTemplateExpr(content string) (*templateExpr, error) {
+	expr := &templateExpr{}
+	if content != "" && strings.ContainsRune("+#./;?&=,!@|", rune(content[0])) {
+		expr.operator, content = content[:1], content[1:]
+		if strings.ContainsAny(expr.operator, "=,!@|") {
+			return nil, fmt.Errorf("operator '%s' is reserved", expr.operator)
+		}
+	}
+	if content == "" {
+		return nil, fmt.Errorf("empty expression")
+	}
+
+	for _, spec := range strings.Split(content, ",") {
+		name := strings.TrimSuffix(spec, "*")
+		if i := strings.Index(name, ":"); i >= 0 {
+			name = name[:i]
+		}
+		if !templateVarName.MatchString(name) {
+			return nil, fmt.Errorf("invalid variable '%s'", spec)
+		}
+		expr.vars = append(expr.vars, name)
+	}
+	return expr, nil
+}
+
+// templateOf returns parsed path template of the test. Paths that can't be
+// parsed are treated as literals, generators report them with validateTestPaths
+func templateOf(test IApiTest) *pathTemplate {
+	tmpl, err := parsePathTemplate(test.Path())
+	if err != nil {
+		return &pathTemplate{raw: test.Path(), parts: []templatePart{{literal: test.Path()}}}
+	}
+	return tmpl
+}
+
+// isQueryVar tells whether the variable is expanded into query string
+// by {?var} or {&var}. Such variables are optional
+func (t *pathTemplate) isQueryVar(name string) bool {
+	for _, part := range t.parts {
+		if part.expr != nil && (part.expr.operator == "?" || part.expr.operator == "&") {
+			if containsString(part.expr.vars, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// vars returns names of all the variables of the template
+func (t *pathTemplate) vars() []string {
+	var vars []string
+	for _, part := range t.parts {
+		if part.expr != nil {
+			vars = append(vars, part.expr.vars...)
+		}
+	}
+	return vars
+}
+
+// docPath renders the template as a path with simple {var} placeholders,
+// as Swagger and RAML expect. Query and fragment expressions are dropped,
+// their variables are documented as query params
+func (t *pathTemplate) docPath() string {
+	buf := &bytes.Buffer{}
+	for _, part := range t.parts {
+		if part.expr == nil {
+			buf.WriteString(part.literal)
+			continue
+		}
+
+		for i, name := range part.expr.vars {
+			switch part.expr.operator {
+			case "", "+":
+				if i > 0 {
+					buf.WriteString(",")
+				}
+				fmt.Fprintf(buf, "{%s}", name)
+			case "/", ".":
+				fmt.Fprintf(buf, "%s{%s}", part.expr.operator, name)
+			case ";":
+				fmt.Fprintf(buf, ";%s={%s}", name, name)
+			}
+		}
+	}
+	return buf.String()
+}
+
+// splitParams splits path params of a test case into the ones expanded
+// into the path and the ones expanded into query string
+func (t *pathTemplate) splitParams(params ParamMap) (path, query ParamMap) {
+	path, query = ParamMap{}, ParamMap{}
+	for name, param := range params {
+		if t.isQueryVar(name) {
+			query[name] = param
+		} else {
+			path[name] = param
+		}
+	}
+	return path, query
+}
+
+// validatePathParams checks that every variable of the path template has
+// a value in PathParams of the test case and every path param is used by
+// the template. Variables of query expressions, like {?q}, are optional, but
+// they can't be set in QueryParams too, or they would be sent twice
+func validatePathParams(path string, testCase ApiTestCase) error {
+	tmpl, err := parsePathTemplate(path)
+	if err != nil {
+		return err
+	}
+
+	vars := tmpl.vars()
+	for _, name := range vars {
+		if _, ok := testCase.PathParams[name]; !ok && !tmpl.isQueryVar(name) {
+			return fmt.Errorf("path template '%s' has no value for '%s'", path, name)
+		}
+	}
+	for _, name := range sortedParamNames(testCase.PathParams) {
+		if !containsString(vars, name) {
+			return fmt.Errorf("path param '%s' is not used by path template '%s'", name, path)
+		}
+		if _, ok := testCase.QueryParams[name]; ok && tmpl.isQueryVar(name) {
+			return fmt.Errorf("param '%s' is set both as a query variable of path template '%s' and as a query param", name, path)
+		}
+	}
+	return nil
+}
+
+// docPathParams returns path params of the test case to document. Cases with
+// SetUp may get path params from it, so template variables they lack are
+// documented with no example value
+func docPathParams(tmpl *pathTemplate, testCase ApiTestCase) ParamMap {
+	if testCase.SetUp == nil {
+		return testCase.PathParams
+	}
+
+	params := ParamMap{}
+	for name, param := range testCase.PathParams {
+		params[name] = param
+	}
+	for _, name := range tmpl.vars() {
+		if _, ok := params[name]; !ok && !tmpl.isQueryVar(name) {
+			params[name] = Param{}
+		}
+	}
+	return params
+}
+
+// docCaseUrl expands URL of the test case for documentation. Path variables
+// the case has no value for are rendered by placeholder, e.g. '{id}'
+func docCaseUrl(test IApiTest, testCase ApiTestCase, baseUrl string, placeholder func(name string) string) (string, error) {
+	tmpl := templateOf(test)
+	params := ParamMap{}
+	for name, param := range testCase.PathParams {
+		params[name] = param
+	}
+
+	// placeholders are expanded as markers that survive escaping
+	markers := map[string]string{}
+	for _, name := range tmpl.vars() {
+		if param, ok := params[name]; (!ok || param.Value == nil) && !tmpl.isQueryVar(name) {
+			marker := fmt.Sprintf("apitestplaceholder%d", len(markers))
+			markers[marker] = placeholder(name)
+			params[name] = Param{Value: marker}
+		}
+	}
+	testCase.PathParams = params
+
+	u, err := testCase.Url(baseUrl + test.Path())
+	if err != nil {
+		return "", err
+	}
+	for marker, value := range markers {
+		u = strings.Replace(u, marker, value, -1)
+	}
+	return u, nil
+}
+
+// validateTestPaths checks path params of all the cases of the tests,
+// so generators don't document paths that can't be expanded. Variables
+// missing in cases with SetUp are not reported, see docPathParams
+func validateTestPaths(tests []IApiTest) error {
+	for _, test := range tests {
+		tmpl := templateOf(test)
+		for _, testCase := range test.TestCases() {
+			testCase.PathParams = docPathParams(tmpl, testCase)
+			if err := validatePathParams(test.Path(), testCase); err != nil {
+				return fmt.Errorf("test '%s', case '%s': %s", extractTestName(test), testCase.Description, err.Error())
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/spec"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type SearchRepoTest struct {
+	pathParams ParamMap
+	retry      *RetryPolicy
+}
+
+func (t *SearchRepoTest) Method() string      { return "GET" }
+func (t *SearchRepoTest) Description() string { return "Test for searching in a repository" }
+func (t *SearchRepoTest) Path() string        { return "/repos/{owner}{/repo}/search{?q}" }
+func (t *SearchRepoTest) TestCases() []ApiTestCase {
+	return []ApiTestCase{
+		{
+			Description:      "Successful search",
+			PathParams:       t.pathParams,
+			QueryParams:      ParamMap{"page": Param{Value: 2}},
+			ExpectedHttpCode: 200,
+			Retry:            t.retry,
+		},
+	}
+}
+
+func TestPathTemplate(t *testing.T) {
+	tmpl, err := parsePathTemplate("/repos/{owner}{/repo,branch}{.format}{;v}{?q,limit*}{#section:3}")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"owner", "repo", "branch", "format", "v", "q", "limit", "section"}, tmpl.vars())
+	assert.Equal(t, "/repos/{owner}/{repo}/{branch}.{format};v={v}", tmpl.docPath())
+	assert.True(t, tmpl.isQueryVar("limit"))
+	assert.False(t, tmpl.isQueryVar("repo"))
+
+	for path, message := range map[string]string{
+		"/repos/{owner":   "path template '/repos/{owner' has unclosed '{'",
+		"/repos/owner}":   "path template '/repos/owner}' has unmatched '}'",
+		"/repos/{}":       "could not parse path template '/repos/{}': empty expression",
+		"/repos/{=owner}": "could not parse path template '/repos/{=owner}': operator '=' is reserved",
+		"/repos/{own er}": "could not parse path template '/repos/{own er}': invalid variable 'own er'",
+	} {
+		_, err := parsePathTemplate(path)
+		assert.EqualError(t, err, message)
+	}
+}
+
+func TestValidatePathParams(t *testing.T) {
+	path := (&SearchRepoTest{}).Path()
+	assert.NoError(t, validatePathParams(path, ApiTestCase{
+		PathParams: ParamMap{"owner": Param{Value: "octocat"}, "repo": Param{Value: "hello"}},
+	}))
+	assert.EqualError(t, validatePathParams(path, ApiTestCase{
+		PathParams: ParamMap{"owner": Param{Value: "octocat"}},
+	}), "path template '/repos/{owner}{/repo}/search{?q}' has no value for 'repo'")
+	assert.EqualError(t, validatePathParams(path, ApiTestCase{
+		PathParams: ParamMap{"owner": Param{Value: "octocat"}, "repo": Param{Value: "hello"}, "id": Param{Value: 1}},
+	}), "path param 'id' is not used by path template '/repos/{owner}{/repo}/search{?q}'")
+	assert.EqualError(t, validatePathParams(path, ApiTestCase{
+		PathParams:  ParamMap{"owner": Param{Value: "octocat"}, "repo": Param{Value: "hello"}, "q": Param{Value: "go"}},
+		QueryParams: ParamMap{"q": Param{Value: "go"}},
+	}), "param 'q' is set both as a query variable of path template '/repos/{owner}{/repo}/search{?q}' and as a query param")
+}
+
+func TestPathTemplateRun(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", "http://testapi.my/repos/octocat/hello/search",
+		httpmock.NewStringResponder(200, ""))
+
+	test := &SearchRepoTest{pathParams: ParamMap{
+		"owner": Param{Value: "octocat"},
+		"repo":  Param{Value: "hello"},
+		"q":     Param{Value: "gopher"},
+	}}
+	url, err := test.TestCases()[0].Url("http://testapi.my" + test.Path())
+	assert.NoError(t, err)
+	assert.Equal(t, "http://testapi.my/repos/octocat/hello/search?page=2&q=gopher", url)
+
+	runner := NewRunner("http://testapi.my", RunnerConfig{})
+	report, err := runner.RunSuite(context.Background(), []IApiTest{test})
+	assert.NoError(t, err)
+	assert.True(t, report.Passed())
+
+	// invalid case is not retried
+	logs := &bytes.Buffer{}
+	runner = NewRunner("http://testapi.my", RunnerConfig{Sink: LogSink(log.New(logs, "", 0))})
+	test = &SearchRepoTest{
+		pathParams: ParamMap{"owner": Param{Value: "octocat"}},
+		retry:      &RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond},
+	}
+	report, err = runner.RunSuite(context.Background(), []IApiTest{test})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"case 'Successful search' is not sent: path template '/repos/{owner}{/repo}/search{?q}' has no value for 'repo'"},
+		report.Tests[0].Cases[0].Failures)
+	assert.NotContains(t, logs.String(), "retrying")
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+}
+
+func TestPathTemplateDocumented(t *testing.T) {
+	test := &SearchRepoTest{pathParams: ParamMap{
+		"owner": Param{Value: "octocat"},
+		"repo":  Param{Value: "hello"},
+		"q":     Param{Value: "gopher"},
+	}}
+	out, err := NewSwaggerGeneratorJSON(spec.Swagger{}).Generate([]IApiTest{test})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	doc := spec.Swagger{}
+	assert.NoError(t, json.Unmarshal(out, &doc))
+	if assert.Contains(t, doc.Paths.Paths, "/repos/{owner}/{repo}/search") {
+		locations := map[string]string{}
+		for _, param := range doc.Paths.Paths["/repos/{owner}/{repo}/search"].Get.Parameters {
+			locations[param.Name] = param.In
+		}
+		assert.Equal(t, map[string]string{"owner": "path", "repo": "path", "q": "query", "page": "query"}, locations)
+	}
+
+	invalid := &SearchRepoTest{pathParams: ParamMap{"owner": Param{Value: "octocat"}}}
+	for _, generator := range []IDocGenerator{
+		NewSwaggerGeneratorJSON(spec.Swagger{}),
+		NewMarkdownGenerator(DocInfo{}),
+		NewHtmlGenerator(DocInfo{}),
+		NewPostmanGenerator(DocInfo{}),
+	} {
+		_, err := generator.Generate([]IApiTest{invalid})
+		assert.EqualError(t, err, "test '*apitest.SearchRepoTest', case 'Successful search': path template '/repos/{owner}{/repo}/search{?q}' has no value for 'repo'")
+	}
+}
@@ -2,12 +2,14 @@ package apitest
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/elgris/jsondiff"
 	"github.com/stretchr/testify/assert"
@@ -42,6 +44,20 @@ type httpRunner struct {
 	DefaultHeaders map[string]string
 	BaseUrl        string
 	HttpClient     IHttpClient
+	Reporters      []IReporter
+	BeforeRequest  []BeforeRequestFunc
+	AfterResponse  []AfterResponseFunc
+	BeforeAll      SuiteHookFunc
+	AfterAll       SuiteHookFunc
+	Vars           map[string]interface{}
+	Auth           IAuthProvider
+	Timeout        time.Duration
+	Sink           IAssertionSink
+	Environment    string
+	Filter         TestFilter
+
+	CheckRequiredParams bool
+	WarnDeprecated      bool
 }
 
 // RunnerConfig contains list of possible options that can be used to initialize
@@ -49,8 +65,78 @@ type httpRunner struct {
 type RunnerConfig struct {
 	DefaultHeaders map[string]string
 	HttpClient     IHttpClient
+
+	// Reporters are notified about progress of the run. Reporters that
+	// implement IFlushable are flushed when the run is finished
+	Reporters []IReporter
+
+	// BeforeRequest is a chain of hooks called in given order before
+	// each request is sent
+	BeforeRequest []BeforeRequestFunc
+	// AfterResponse is a chain of hooks called in given order after
+	// each response is received
+	AfterResponse []AfterResponseFunc
+
+	// BeforeAll is called once before any test is run. If it fails,
+	// no tests are run
+	BeforeAll SuiteHookFunc
+	// AfterAll is called once after all tests are run, even if some
+	// of them failed
+	AfterAll SuiteHookFunc
+
+	// Vars are initial variables shared by all hooks of the run
+	Vars map[string]interface{}
+
+	// Auth authenticates each request. It's applied after all the request
+	// hooks, so signatures cover the final request. Tests may override it
+	// by implementing IAuthOverride
+	Auth IAuthProvider
+
+	// Timeout limits time of each request made by the runner, including
+	// reading of the response. Test cases may override it with
+	// ApiTestCase.Timeout. 30 seconds by default, negative value disables it
+	Timeout time.Duration
+
+	// Sink receives failures and messages of the run started with RunSuite.
+	// They are discarded by default, since they are also in the report
+	Sink IAssertionSink
+
+	// Environment is a name of environment the runner runs against. Tests
+	// that implement IEnvironmentOptOut are skipped in excluded environments
+	Environment string
+
+	// Filter selects tests to run. Tests are also filtered by -apitest.tags,
+	// -apitest.run, -apitest.method and -apitest.path flags
+	Filter TestFilter
+
+	// CheckRequiredParams enables negative cases derived from each 2xx case:
+	// every required header, query or path param is dropped in turn and the
+	// API is expected to respond with 4xx. Path segments are expanded empty,
+	// e.g. '/user/' for '/user/{id}'. Tests may require particular code by
+	// implementing IMissingParamCode
+	CheckRequiredParams bool
+
+	// WarnDeprecated makes the runner log a warning for each case that
+	// still depends on deprecated endpoint or params, see IDeprecatable
+	WarnDeprecated bool
+}
+
+// CaseContext provides hooks with access to the runner
+type CaseContext struct {
+	BaseUrl    string
+	HttpClient IHttpClient
+	// Context is the context of the run, requests made by hooks should
+	// be bound to it, so they stop once the run is cancelled
+	Context context.Context
+
+	// Vars are shared by all hooks of the runner, so data created by one
+	// hook can be used by another one
+	Vars map[string]interface{}
 }
 
+// SuiteHookFunc defines function that is called before or after all the tests
+type SuiteHookFunc func(ctx *CaseContext) error
+
 // NewRunner creates new instance of HTTP runner
 func NewRunner(baseUrl string, config RunnerConfig) *httpRunner {
 	r := &httpRunner{
@@ -65,107 +151,335 @@ func NewRunner(baseUrl string, config RunnerConfig) *httpRunner {
 	if config.HttpClient != nil {
 		r.HttpClient = config.HttpClient
 	}
+	r.Reporters = config.Reporters
+	r.BeforeRequest = config.BeforeRequest
+	r.AfterResponse = config.AfterResponse
+	r.BeforeAll = config.BeforeAll
+	r.AfterAll = config.AfterAll
+	r.Auth = config.Auth
+	r.CheckRequiredParams = config.CheckRequiredParams
+	r.WarnDeprecated = config.WarnDeprecated
+	r.Timeout = config.Timeout
+	if r.Timeout == 0 {
+		r.Timeout = defaultTimeout
+	}
+	r.Sink = config.Sink
+	r.Environment = config.Environment
+	r.Filter = config.Filter
+
+	r.Vars = make(map[string]interface{})
+	for name, value := range config.Vars {
+		r.Vars[name] = value
+	}
 
 	return r
 }
 
+// Run runs tests reporting failures to given test. It's a thin adapter
+// over RunSuite for use in go test
 func (r *httpRunner) Run(t *testing.T, tests ...IApiTest) {
-	for _, test := range tests {
-		testName := extractTestName(test)
-		// setup test
-		if setuppable, ok := test.(ISetuppable); ok {
-			t.Logf("setting up test '%s'(%s)...", testName, test.Description())
+	r.RunContext(context.Background(), t, tests...)
+}
 
-			if err := setuppable.SetUp(); err != nil {
-				t.Errorf("error setting up test '%s'(%s): %s",
-					testName, test.Description(), err.Error())
+// RunContext runs tests with given context. Every request made by the
+// runner is bound to the context, so the run stops once the context is
+// cancelled
+func (r *httpRunner) RunContext(ctx context.Context, t *testing.T, tests ...IApiTest) {
+	// all the errors are already reported to the test
+	r.runSuite(ctx, t, tests)
+}
 
-				continue
-			}
-		}
+func (r *httpRunner) encode(obj interface{}) ([]byte, error) {
+	// TODO: make it configurable
+	return json.Marshal(obj)
+}
 
-		// run test
-		for caseIndex, testCase := range test.TestCases() {
-			t.Logf("running test '%s'(%s), case %d", testName, test.Description(), caseIndex)
-			r.runTest(t, testCase, test.Method(), test.Path())
-		}
+// caseT passes assertions of a test case through to the test and
+// collects messages of failed ones
+type caseT struct {
+	sink     IAssertionSink
+	failures []string
 
-		// teardown test
-		if teardownable, ok := test.(ITeardownable); ok {
-			t.Logf("tearing down test '%s'(%s)...", testName, test.Description())
+	// buffered caseT keeps the output until it's flushed, so failed
+	// attempts of retried cases don't fail the test
+	buffered bool
+	output   []caseOutput
+}
 
-			if err := teardownable.TearDown(); err != nil {
-				t.Errorf("error cleaning up after a test '%s'(%s): %s",
-					testName, test.Description(), err.Error())
-			}
+// caseOutput is a message of buffered caseT
+type caseOutput struct {
+	message string
+	failure bool
+}
+
+func (t *caseT) Errorf(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	t.failures = append(t.failures, message)
+	if t.buffered {
+		t.output = append(t.output, caseOutput{message: message, failure: true})
+		return
+	}
+	t.sink.Errorf("%s", message)
+}
+
+func (t *caseT) Logf(format string, args ...interface{}) {
+	if t.buffered {
+		t.output = append(t.output, caseOutput{message: fmt.Sprintf(format, args...)})
+		return
+	}
+	t.sink.Logf(format, args...)
+}
+
+// recordFailure records a failure that has already been reported to the test
+func (t *caseT) recordFailure(message string) {
+	t.failures = append(t.failures, message)
+}
+
+// flushTo passes buffered output to given caseT
+func (t *caseT) flushTo(parent *caseT) {
+	for _, output := range t.output {
+		switch {
+		case output.failure:
+			parent.Errorf("%s", output.message)
+		default:
+			parent.Logf("%s", output.message)
 		}
 	}
+	t.output = nil
 }
 
-func (r *httpRunner) encode(obj interface{}) ([]byte, error) {
-	// TODO: make it configurable
-	return json.Marshal(obj)
+func (r *httpRunner) runTest(ctx context.Context, sink IAssertionSink, test IApiTest, testCase ApiTestCase) CaseResult {
+	result := CaseResult{
+		TestName:         extractTestName(test),
+		Description:      testCase.Description,
+		Method:           test.Method(),
+		ExpectedHttpCode: testCase.ExpectedHttpCode,
+	}
+
+	if skipCase(sink, testCase, &result) {
+		return result
+	}
+	if err := validateAssertions(testCase); err != nil {
+		sink.Errorf("%s", err.Error())
+		result.Failures = []string{err.Error()}
+		return result
+	}
+	// derived cases depend on the same inputs, no need to warn again
+	if r.WarnDeprecated && testCase.missing == nil {
+		warnDeprecated(sink, test, testCase)
+	}
+
+	ct := &caseT{sink: sink}
+	if testCase.ExpectedFailure != "" {
+		buffered := &caseT{sink: sink, buffered: true}
+		r.executeCaseWithBudget(ctx, buffered, test, testCase, &result)
+		settleExpectedFailure(ct, buffered, testCase, &result)
+	} else {
+		r.executeCaseWithBudget(ctx, ct, test, testCase, &result)
+	}
+
+	result.Failures = ct.failures
+	result.Passed = len(result.Failures) == 0
+
+	return result
 }
 
-func (r *httpRunner) runTest(t *testing.T, testCase ApiTestCase, method, path string) {
-	urlstring := r.BaseUrl + path
-	url, err := testCase.Url(urlstring)
-	if !assert.NoError(t, err, "could not prepare an url") {
-		return
+func (r *httpRunner) caseContext(ctx context.Context) *CaseContext {
+	return &CaseContext{
+		BaseUrl:    r.BaseUrl,
+		HttpClient: r.HttpClient,
+		Context:    ctx,
+		Vars:       r.Vars,
 	}
+}
 
-	// TODO: prepare body
-	var req *http.Request
-	if testCase.RequestBody != nil {
-		encoded, err := r.encode(testCase.RequestBody)
-		if !assert.NoError(t, err, "could not encode body") {
+// executeCaseWithHooks runs setup and teardown logic of the test case around it
+func (r *httpRunner) executeCaseWithHooks(ctx context.Context, t *caseT, test IApiTest, testCase ApiTestCase, result *CaseResult) {
+	caseCtx := r.caseContext(ctx)
+	if testCase.SetUp != nil {
+		if err := testCase.SetUp(caseCtx, &testCase); err != nil {
+			t.Errorf("error setting up case '%s': %s", testCase.Description, err.Error())
 			return
 		}
+	}
 
-		requestBody := bytes.NewBuffer(encoded)
-		req, err = http.NewRequest(method, url, requestBody)
-	} else {
-		req, err = http.NewRequest(method, url, nil)
+	if testCase.TearDown != nil {
+		// deferred, so teardown happens even if assertion stops the test
+		defer func() {
+			if err := testCase.TearDown(caseCtx, &testCase); err != nil {
+				t.Errorf("error tearing down case '%s': %s", testCase.Description, err.Error())
+			}
+		}()
 	}
 
-	if !assert.NoError(t, err, "could not create HTTP request") {
+	// params are validated once SetUp has provided them, not on every attempt
+	if err := validateCase(test, testCase); err != nil {
+		t.Errorf("case '%s' is not sent: %s", testCase.Description, err.Error())
 		return
 	}
 
-	for name, value := range r.DefaultHeaders {
-		req.Header.Set(name, value)
+	r.executeCaseWithRetries(ctx, t, test, testCase, result)
+}
+
+// validateAssertions checks that custom AssertResponse is not used by a case
+// whose failures must be taken back: AssertResponse reports them right to
+// the test, failed attempts and expected failures would fail it
+func validateAssertions(testCase ApiTestCase) error {
+	if testCase.AssertResponse == nil {
+		return nil
 	}
-	for name, param := range testCase.Headers {
-		if stringValue, ok := param.Value.(string); ok {
-			req.Header.Set(name, stringValue)
-		} else {
-			req.Header.Set(name, fmt.Sprintf("%v", param.Value))
+	if testCase.Retry != nil || testCase.WaitUntil != nil || testCase.ExpectedFailure != "" {
+		return fmt.Errorf("custom AssertResponse of case '%s' can't be used with Retry, WaitUntil or ExpectedFailure, use AssertBody instead",
+			testCase.Description)
+	}
+	return nil
+}
+
+// validateCase checks params of the test case before it's sent
+func validateCase(test IApiTest, testCase ApiTestCase) error {
+	if err := validatePathParams(test.Path(), testCase); err != nil {
+		return err
+	}
+	if testCase.ExpectedHttpCode >= 200 && testCase.ExpectedHttpCode < 300 {
+		return validateParams(testCase)
+	}
+	return nil
+}
+
+func (r *httpRunner) executeCase(ctx context.Context, t *caseT, test IApiTest, testCase ApiTestCase, result *CaseResult) {
+	req, requestBody, err := r.newRequest(testCase, test.Method(), test.Path())
+	if !assert.NoError(t, err, "could not prepare HTTP request") {
+		return
+	}
+	if !assert.NoError(t, r.beforeRequest(req, test, testCase), "request hook failed") {
+		return
+	}
+	// hooks may change the body, so it's read again to be reproduced
+	if requestBody, err = snapshotRequestBody(req); !assert.NoError(t, err, "could not read request body") {
+		return
+	}
+	// the URL is recorded before credentials can be added to it,
+	// since it ends up in reports
+	result.Url = req.URL.String()
+
+	// auth is bound to the request timeout too, e.g. for OAuth2 token requests
+	req, timeout, cancel := r.withTimeout(ctx, req, testCase)
+	defer cancel()
+	var creds credentials
+	if auth := r.authProviderFor(test); auth != nil {
+		if creds, err = authenticate(auth, req); !assert.NoError(t, err, "could not authenticate request") {
+			return
 		}
 	}
+	// logged copy of the request, with no credentials
+	logReq := redactRequest(req, creds)
 
+	started := time.Now()
 	resp, err := r.HttpClient.Do(req)
-	if !assert.NoError(t, err, "failed sending a request") {
+	result.Duration = time.Since(started)
+	if err != nil && req.Context().Err() != nil {
+		t.Errorf("%s", requestContextError(logReq, testCase, timeout))
+		t.Logf("request:\n%s", requestToCurl(logReq, requestBody))
+		return
+	}
+	if !assert.NoError(t, redactError(err, logReq), "failed sending a request") {
+		t.Logf("request:\n%s", requestToCurl(logReq, requestBody))
 		return
 	}
 
-	if !assert.NotNil(t, resp, "request to '%s' returned nil response", urlstring) {
+	if !assert.NotNil(t, resp, "request to '%s' returned nil response", logReq.URL.String()) {
 		return
 	}
+	result.HttpCode = resp.StatusCode
 
 	var responseBody []byte
 	if resp.Body != nil {
 		defer resp.Body.Close()
 
 		responseBody, err = ioutil.ReadAll(resp.Body)
+		if err != nil && req.Context().Err() != nil {
+			t.Errorf("%s", requestContextError(logReq, testCase, timeout))
+			return
+		}
 		if !assert.NoError(t, err) {
 			return
 		}
 	}
 
+	responseBody, err = r.afterResponse(resp, responseBody, test, testCase)
+	if !assert.NoError(t, err, "response hook failed") {
+		return
+	}
+	result.HttpCode = resp.StatusCode
+
+	if !r.assertResponse(t, testCase, resp, responseBody) {
+		t.Logf("request:\n%s", requestToCurl(logReq, requestBody))
+		t.Logf("response headers:\n%s", formatHeaders(resp.Header))
+	}
+}
+
+// newRequest prepares HTTP request for given test case. Returns the request
+// and its encoded body, so the request can be reproduced later.
+func (r *httpRunner) newRequest(testCase ApiTestCase, method, path string) (*http.Request, []byte, error) {
+	testCase = testCase.withoutMissingParam()
+	urlstring := r.BaseUrl + path
+	url, err := testCase.Url(urlstring)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not prepare an url: %s", err.Error())
+	}
+
+	// TODO: prepare body
+	var req *http.Request
+	var encoded []byte
+	if testCase.RequestBody != nil {
+		encoded, err = r.encode(testCase.RequestBody)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not encode body: %s", err.Error())
+		}
+
+		requestBody := bytes.NewBuffer(encoded)
+		req, err = http.NewRequest(method, url, requestBody)
+	} else {
+		req, err = http.NewRequest(method, url, nil)
+	}
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not create HTTP request: %s", err.Error())
+	}
+
+	for name, value := range r.DefaultHeaders {
+		req.Header.Set(name, value)
+	}
+	for name, param := range testCase.Headers {
+		items, ok := paramItems(param.Value)
+		if !ok || param.CollectionFormat != CollectionMulti {
+			req.Header.Set(name, paramValueString(param))
+			continue
+		}
+
+		req.Header.Del(name)
+		for _, item := range itemStrings(items) {
+			req.Header.Add(name, item)
+		}
+	}
+	if testCase.missing != nil && testCase.missing.kind == paramKindHeader {
+		// the header must not be sent even if it's set by default
+		req.Header.Del(testCase.missing.name)
+	}
+
+	return req, encoded, nil
+}
+
+// assertResponse checks that given response matches expectations of the test case
+func (r *httpRunner) assertResponse(t *caseT, testCase ApiTestCase, resp *http.Response, responseBody []byte) bool {
+	if testCase.missing != nil {
+		return assertMissingParamRejected(t, testCase, resp, responseBody)
+	}
+
 	if !assert.Equal(t, testCase.ExpectedHttpCode, resp.StatusCode) {
 		t.Logf("body received: %s", string(responseBody))
 
-		return
+		return false
 	}
 
 	// asserting headers
@@ -174,21 +488,47 @@ func (r *httpRunner) runTest(t *testing.T, testCase ApiTestCase, method, path st
 			if !assert.Equal(t, value, resp.Header.Get(header)) {
 				t.Logf("body received: %s", string(responseBody))
 
-				return
+				return false
 			}
 		}
 	}
 
 	if testCase.AssertResponse != nil {
-		testCase.AssertResponse(t, testCase.ExpectedData, responseBody)
-	} else {
-		AssertResponse(t, testCase.ExpectedData, responseBody)
+		test, ok := t.sink.(*testing.T)
+		if !ok {
+			t.Errorf("custom AssertResponse of case '%s' can only be run by go test, use AssertBody instead",
+				testCase.Description)
+			return false
+		}
+
+		// custom assertion talks to the test directly, so its failures
+		// can only be detected by result
+		if !testCase.AssertResponse(test, testCase.ExpectedData, responseBody) {
+			t.recordFailure("custom response assertion failed")
+			return false
+		}
+		return true
 	}
+	if testCase.AssertBody != nil {
+		return testCase.AssertBody(t, testCase.ExpectedData, responseBody)
+	}
+
+	return assertResponseBody(t, testCase.ExpectedData, responseBody)
 }
 
 // AssertResponse checks that given expected object contains the same data
 // as provided responseBody.
 func AssertResponse(t *testing.T, expected interface{}, responseBody []byte) bool {
+	return assertResponseBody(t, expected, responseBody)
+}
+
+// AssertResponseBody is the same as AssertResponse, but it doesn't need
+// go test to be run
+func AssertResponseBody(t IAssertionSink, expected interface{}, responseBody []byte) bool {
+	return assertResponseBody(t, expected, responseBody)
+}
+
+func assertResponseBody(t assert.TestingT, expected interface{}, responseBody []byte) bool {
 	if expected != nil {
 		expectedData := decodeExpected(expected)
 		actualData := decodeResponse(responseBody)
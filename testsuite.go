@@ -1,9 +1,8 @@
 package apitest
 
 import (
-	"fmt"
-	"net/url"
 	"testing"
+	"time"
 
 	"github.com/jingweno/go-sawyer/hypermedia"
 )
@@ -37,6 +36,15 @@ type ITeardownable interface {
 // given response body
 type AssertResponseFunc func(t *testing.T, expected interface{}, responseBody []byte) bool
 
+// AssertBodyFunc defines function that asserts that expected object equals to
+// given response body. Unlike AssertResponseFunc, it can be used outside of go test
+type AssertBodyFunc func(t IAssertionSink, expected interface{}, responseBody []byte) bool
+
+// CaseHookFunc defines function that is called before or after a test case
+// is run. It has access to the runner via ctx and may modify the test case,
+// e.g. put ID of freshly created fixture into PathParams
+type CaseHookFunc func(ctx *CaseContext, testCase *ApiTestCase) error
+
 // ApiTestCase provides use case of some API endpoint: input and expected output.
 //
 // Test case knows nothing about API endpoint itself. Path of an API endpoint and
@@ -61,6 +69,54 @@ type ApiTestCase struct {
 	// for processing of API response payload and assertion with
 	// expected data.
 	AssertResponse AssertResponseFunc
+	// AssertBody is the same as AssertResponse, but doesn't depend on go
+	// test, so the case can be run with RunSuite. AssertResponse takes
+	// precedence if both are provided. Cases with Retry, WaitUntil or
+	// ExpectedFailure must use AssertBody
+	AssertBody AssertBodyFunc
+
+	// SetUp is called right before the case is run. If it fails, the
+	// case is not run
+	SetUp CaseHookFunc
+	// TearDown is called after the case is run, even if assertions
+	// of the case fail. It is not called if SetUp fails
+	TearDown CaseHookFunc
+
+	// Timeout overrides RunnerConfig.Timeout for requests of the case
+	Timeout time.Duration
+
+	// MaxDuration fails the case if round trip of its request takes
+	// longer. Tests may provide default budget by implementing IMaxDuration
+	MaxDuration time.Duration
+	// Repeat runs the case given number of times, so latency percentiles
+	// can be measured and checked against MaxP50 and MaxP95. SetUp and
+	// TearDown are called for each run
+	Repeat int
+	MaxP50 time.Duration
+	MaxP95 time.Duration
+
+	// Retry makes the runner run the case again if it fails
+	Retry *RetryPolicy
+	// WaitUntil makes the runner poll the endpoint until assertions of
+	// the case pass or the timeout elapses. Useful for endpoints backed by
+	// async workers. Retry is ignored if WaitUntil is set
+	WaitUntil *WaitPolicy
+
+	// Skip is a reason the case is not run for. Skipped cases are still
+	// documented, generators wrapped by FlagUnstableCases flag them as unstable
+	Skip string
+	// Pending is a reason the case is not run for yet, e.g. the endpoint
+	// is not implemented. Pending cases are documented the same way as
+	// skipped ones
+	Pending string
+	// ExpectedFailure is a reason the case is known to fail for, e.g. a
+	// link to the bug. The case is run, its failures are logged only and it
+	// fails if it unexpectedly passes
+	ExpectedFailure string
+
+	// missing is set for cases derived by the runner to check that
+	// the API rejects requests with missing required parameter
+	missing *missingParam
 }
 
 type ParamMap map[string]Param
@@ -71,16 +127,41 @@ type Param struct {
 	Value       interface{}
 	Required    bool
 	Description string
+	// Deprecated marks the param as deprecated in generated documentation
+	Deprecated bool
+
+	// Example is shown in documentation instead of Value if provided
+	Example interface{}
+
+	// Constraints of the param. They are documented and Value is checked
+	// against them before request of a 2xx case is sent. Negative cases
+	// are not checked, so they can send invalid values deliberately
+	Enum      []interface{}
+	Format    string
+	Minimum   *float64
+	Maximum   *float64
+	Pattern   string
+	MinLength *int64
+	MaxLength *int64
+	// CollectionFormat tells how items of slice Value are encoded: csv
+	// (default), ssv, tsv, pipes or multi. Items of multi params are sent
+	// as repeated query keys or header values. Swagger allows multi for
+	// query params only, so multi headers are documented as csv
+	CollectionFormat string
 }
 
 // Url generates full URL to API endpoint for given test case.
-// urlpath must provide full URL to the endpoint with no query parameters.
+// urlpath must provide full URL to the endpoint with no query parameters,
+// other than RFC 6570 query expressions like {?q}. Variables of the template
+// are not checked against PathParams, see validatePathParams
 func (testCase *ApiTestCase) Url(urlpath string) (string, error) {
-	// TODO: what if url template contains something, but it's not provided by test case?
 	sawyerHyperlink := hypermedia.Hyperlink(urlpath)
 	params := hypermedia.M{}
 	for name, p := range testCase.PathParams {
 		params[name] = p.Value
+		if _, ok := paramItems(p.Value); ok {
+			params[name] = paramValueString(p)
+		}
 	}
 
 	u, err := sawyerHyperlink.Expand(params)
@@ -88,10 +169,12 @@ func (testCase *ApiTestCase) Url(urlpath string) (string, error) {
 		return "", err
 	}
 	if testCase.QueryParams != nil {
-		query := url.Values{}
+		// query expanded from the template is kept
+		query := u.Query()
 		for key, param := range testCase.QueryParams {
-			valueStr := fmt.Sprintf("%v", param.Value)
-			query.Set(key, valueStr)
+			for _, value := range paramQueryValues(param) {
+				query.Add(key, value)
+			}
 		}
 		u.RawQuery = query.Encode()
 	}
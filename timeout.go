@@ -0,0 +1,40 @@
+package apitest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// defaultTimeout limits requests of runners with no timeout set, so a hung
+// server doesn't hang the run
+const defaultTimeout = 30 * time.Second
+
+// withTimeout binds the request to given context limited by timeout of the
+// test case or the runner. Returns the timeout applied, 0 if there is none
+func (r *httpRunner) withTimeout(ctx context.Context, req *http.Request, testCase ApiTestCase) (*http.Request, time.Duration, context.CancelFunc) {
+	timeout := r.Timeout
+	if testCase.Timeout > 0 {
+		timeout = testCase.Timeout
+	}
+
+	cancel := context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+
+	return req.WithContext(ctx), timeout, cancel
+}
+
+// requestContextError explains why the request of the test case was interrupted
+func requestContextError(req *http.Request, testCase ApiTestCase, timeout time.Duration) string {
+	if req.Context().Err() == context.DeadlineExceeded {
+		if timeout > 0 {
+			return fmt.Sprintf("case '%s' timed out after %s: %s %s", testCase.Description, timeout, req.Method, req.URL)
+		}
+		return fmt.Sprintf("case '%s' exceeded deadline of the run: %s %s", testCase.Description, req.Method, req.URL)
+	}
+
+	return fmt.Sprintf("case '%s' was cancelled: %s %s", testCase.Description, req.Method, req.URL)
+}
@@ -0,0 +1,133 @@
+package apitest
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type SlowTest struct {
+	timeout time.Duration
+}
+
+func (t *SlowTest) Method() string      { return "GET" }
+func (t *SlowTest) Description() string { return "Test for slow endpoint" }
+func (t *SlowTest) Path() string        { return "/slow" }
+func (t *SlowTest) TestCases() []ApiTestCase {
+	return []ApiTestCase{
+		{
+			Description:      "Slow response",
+			ExpectedHttpCode: 200,
+			Timeout:          t.timeout,
+		},
+	}
+}
+
+// slowClient responds after given delay unless the request is cancelled
+func slowClient(delay time.Duration) IHttpClient {
+	return IHttpClientFunc(func(req *http.Request) (*http.Response, error) {
+		select {
+		case <-time.After(delay):
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	})
+}
+
+func TestRunnerTimeout(t *testing.T) {
+	collector := &resultCollector{}
+	runner := NewRunner("http://testapi.my", RunnerConfig{
+		HttpClient: slowClient(time.Second),
+		Reporters:  []IReporter{collector},
+		Timeout:    10 * time.Millisecond,
+	})
+	runner.Run(new(testing.T), &SlowTest{})
+
+	result := collector.flush()[0].Cases[0]
+	assert.False(t, result.Passed)
+	assert.Equal(t, []string{"case 'Slow response' timed out after 10ms: GET http://testapi.my/slow"}, result.Failures)
+	assert.True(t, result.Duration < time.Second)
+}
+
+func TestCaseTimeoutOverride(t *testing.T) {
+	runner := NewRunner("http://testapi.my", RunnerConfig{
+		HttpClient: slowClient(5 * time.Millisecond),
+		Timeout:    time.Millisecond,
+	})
+	runner.Run(t, &SlowTest{timeout: time.Second})
+}
+
+func TestRunContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	requests := 0
+	collector := &resultCollector{}
+	runner := NewRunner("http://testapi.my", RunnerConfig{
+		HttpClient: IHttpClientFunc(func(req *http.Request) (*http.Response, error) {
+			requests++
+			return nil, req.Context().Err()
+		}),
+		Reporters: []IReporter{collector},
+	})
+	mockT := new(testing.T)
+	runner.RunContext(ctx, mockT, &SlowTest{})
+
+	assert.True(t, mockT.Failed())
+	assert.Equal(t, 0, requests)
+	assert.Empty(t, collector.flush()[0].Cases)
+}
+
+func TestDefaultTimeout(t *testing.T) {
+	assert.Equal(t, defaultTimeout, NewRunner("http://testapi.my", RunnerConfig{}).Timeout)
+
+	// a negative timeout disables it
+	runner := NewRunner("http://testapi.my", RunnerConfig{
+		HttpClient: IHttpClientFunc(func(req *http.Request) (*http.Response, error) {
+			_, ok := req.Context().Deadline()
+			assert.False(t, ok)
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+		}),
+		Timeout: -1,
+	})
+	runner.Run(t, &SlowTest{})
+}
+
+func TestRunContextReachesHooksAndAuth(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tokenRequested := false
+	auth := OAuth2ClientCredentials(OAuth2Config{
+		TokenUrl: "http://auth.my/token",
+		HttpClient: IHttpClientFunc(func(req *http.Request) (*http.Response, error) {
+			tokenRequested = true
+			_, ok := req.Context().Deadline()
+			assert.True(t, ok, "token request is bound to the request timeout")
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(`{"access_token":"token"}`))}, nil
+		}),
+	})
+
+	hooked := 0
+	hook := func(caseCtx *CaseContext) error {
+		hooked++
+		assert.Equal(t, ctx, caseCtx.Context)
+		return nil
+	}
+	runner := NewRunner("http://testapi.my", RunnerConfig{
+		HttpClient: slowClient(0),
+		Auth:       auth,
+		BeforeAll:  hook,
+		AfterAll:   hook,
+	})
+	runner.RunContext(ctx, t, &SlowTest{})
+
+	assert.True(t, tokenRequested)
+	assert.Equal(t, 2, hooked)
+}